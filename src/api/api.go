@@ -0,0 +1,213 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"timetrack-sync/src/plan"
+	"timetrack-sync/src/report"
+	"timetrack-sync/src/sloneek"
+	"timetrack-sync/src/validation"
+
+	"github.com/rs/zerolog"
+)
+
+// maxBodySize limits sync request bodies, a range and a flag need far less.
+const maxBodySize = 64 << 10
+
+// ErrInvalidRange is returned for ranges the engine cannot sync, it is answered with 400.
+var ErrInvalidRange = errors.New("Invalid range")
+
+// Engine is the sync engine the API exposes. Ranges are [since, until) in local days.
+type Engine interface {
+	Preview(ctx context.Context, since time.Time, until time.Time) (*Mapped, error)
+	Sync(ctx context.Context, since time.Time, until time.Time, dryRun bool) (*plan.Plan, error)
+}
+
+// Mapped are Sloneek entries mapped from Toggl together with lookups needed to describe them.
+type Mapped struct {
+	Entries    []sloneek.TimeEntry
+	Activities []sloneek.Activity
+	Categories []sloneek.Category
+}
+
+type PreviewEntry struct {
+	TogglEntryId int64     `json:"toggl_entry_id"`
+	Activity     string    `json:"activity"`
+	Category     string    `json:"category"`
+	Since        time.Time `json:"since"`
+	Until        time.Time `json:"until"`
+	Hours        float64   `json:"hours"`
+}
+
+type SyncRequest struct {
+	Since  string `json:"since"`
+	Until  string `json:"until"`
+	DryRun bool   `json:"dry_run"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type Server struct {
+	Engine   Engine
+	Location *time.Location
+	// Token, when set, must be sent as "Authorization: Bearer <token>"
+	Token  string
+	Logger *zerolog.Logger
+}
+
+func (server *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sync", server.allowMethod(http.MethodPost, server.handleSync))
+	mux.HandleFunc("/preview", server.allowMethod(http.MethodGet, server.handlePreview))
+	mux.HandleFunc("/report", server.allowMethod(http.MethodGet, server.handleReport))
+
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if server.Token != "" {
+			expected := []byte("Bearer " + server.Token)
+			if subtle.ConstantTimeCompare([]byte(request.Header.Get("Authorization")), expected) != 1 {
+				server.writeJson(writer, http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+				return
+			}
+		}
+
+		mux.ServeHTTP(writer, request)
+	})
+}
+
+func (server *Server) allowMethod(method string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method != method {
+			writer.Header().Set("Allow", method)
+			server.writeJson(writer, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+			return
+		}
+
+		handler(writer, request)
+	}
+}
+
+func (server *Server) writeJson(writer http.ResponseWriter, status int, payload any) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	err := json.NewEncoder(writer).Encode(payload)
+	if err != nil {
+		server.Logger.Error().Err(err).Msg("Error while writing response")
+	}
+}
+
+func (server *Server) writeError(writer http.ResponseWriter, status int, err error) {
+	server.Logger.Error().Err(err).Int("status", status).Msg("API request failed")
+	server.writeJson(writer, status, errorResponse{Error: err.Error()})
+}
+
+// engineStatus answers rejected input with 4xx, other failures come from Toggl or Sloneek.
+func engineStatus(err error) int {
+	switch {
+	case errors.Is(err, validation.ErrPolicyViolated):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, ErrInvalidRange):
+		return http.StatusBadRequest
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+func (server *Server) parseRange(sinceValue string, untilValue string) (time.Time, time.Time, error) {
+	since, err := time.ParseInLocation(time.DateOnly, sinceValue, server.Location)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: since %q, expected YYYY-MM-DD", ErrInvalidRange, sinceValue)
+	}
+
+	until, err := time.ParseInLocation(time.DateOnly, untilValue, server.Location)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: until %q, expected YYYY-MM-DD", ErrInvalidRange, untilValue)
+	}
+
+	if !until.After(since) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: until must be after since", ErrInvalidRange)
+	}
+
+	return since, until, nil
+}
+
+func (server *Server) handleSync(writer http.ResponseWriter, request *http.Request) {
+	var payload SyncRequest
+	err := json.NewDecoder(http.MaxBytesReader(writer, request.Body, maxBodySize)).Decode(&payload)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		server.writeError(writer, http.StatusRequestEntityTooLarge, errors.New("Body too large"))
+		return
+	}
+	if err != nil {
+		server.writeError(writer, http.StatusBadRequest, errors.New("Invalid JSON body"))
+		return
+	}
+
+	since, until, err := server.parseRange(payload.Since, payload.Until)
+	if err != nil {
+		server.writeError(writer, http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := server.Engine.Sync(request.Context(), since, until, payload.DryRun)
+	if err != nil {
+		server.writeError(writer, engineStatus(err), err)
+		return
+	}
+
+	server.writeJson(writer, http.StatusOK, result)
+}
+
+func (server *Server) previewRange(writer http.ResponseWriter, request *http.Request) (*Mapped, bool) {
+	query := request.URL.Query()
+	since, until, err := server.parseRange(query.Get("since"), query.Get("until"))
+	if err != nil {
+		server.writeError(writer, http.StatusBadRequest, err)
+		return nil, false
+	}
+
+	mapped, err := server.Engine.Preview(request.Context(), since, until)
+	if err != nil {
+		server.writeError(writer, engineStatus(err), err)
+		return nil, false
+	}
+
+	return mapped, true
+}
+
+func (server *Server) handlePreview(writer http.ResponseWriter, request *http.Request) {
+	mapped, ok := server.previewRange(writer, request)
+	if !ok {
+		return
+	}
+
+	entries := make([]PreviewEntry, len(mapped.Entries))
+	for i, entry := range mapped.Entries {
+		entries[i] = PreviewEntry{
+			TogglEntryId: entry.SourceId,
+			Activity:     sloneek.ActivityName(mapped.Activities, entry.ActivityId),
+			Category:     sloneek.CategoryName(mapped.Categories, entry.CategoryId),
+			Since:        entry.Since,
+			Until:        entry.Until,
+			Hours:        entry.GetHours(),
+		}
+	}
+
+	server.writeJson(writer, http.StatusOK, entries)
+}
+
+func (server *Server) handleReport(writer http.ResponseWriter, request *http.Request) {
+	mapped, ok := server.previewRange(writer, request)
+	if !ok {
+		return
+	}
+
+	server.writeJson(writer, http.StatusOK, report.Build(mapped.Entries, mapped.Activities, mapped.Categories, server.Location))
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"timetrack-sync/src/plan"
+	"timetrack-sync/src/report"
+	"timetrack-sync/src/sloneek"
+	testutils "timetrack-sync/src/testUtils"
+	"timetrack-sync/src/validation"
+
+	"github.com/rs/zerolog"
+)
+
+type fakeEngine struct {
+	mapped     *Mapped
+	err        error
+	syncSince  time.Time
+	syncDryRun bool
+}
+
+func (engine *fakeEngine) Preview(ctx context.Context, since time.Time, until time.Time) (*Mapped, error) {
+	return engine.mapped, engine.err
+}
+
+func (engine *fakeEngine) Sync(ctx context.Context, since time.Time, until time.Time, dryRun bool) (*plan.Plan, error) {
+	engine.syncSince = since
+	engine.syncDryRun = dryRun
+	return &plan.Plan{Since: since, Until: until, Operations: []plan.Operation{{Kind: plan.Create}}}, engine.err
+}
+
+func startServer(t *testing.T, engine *fakeEngine, token string) *httptest.Server {
+	t.Helper()
+	server := &Server{Engine: engine, Location: time.UTC, Token: token, Logger: &zerolog.Logger{}}
+	httpServer := httptest.NewServer(server.Handler())
+	t.Cleanup(httpServer.Close)
+
+	return httpServer
+}
+
+func testMapped(t *testing.T) *Mapped {
+	return &Mapped{
+		Entries: []sloneek.TimeEntry{
+			{SourceId: 7, ActivityId: "a1", Since: testutils.DateTimeFromString("2024-09-02 08:00:00", t), Until: testutils.DateTimeFromString("2024-09-02 09:30:00", t)},
+		},
+		Activities: []sloneek.Activity{{Id: "a1", Name: "Meeting"}},
+	}
+}
+
+func TestPreviewReturnsMappedEntries(t *testing.T) {
+	server := startServer(t, &fakeEngine{mapped: testMapped(t)}, "")
+
+	response, err := http.Get(server.URL + "/preview?since=2024-09-01&until=2024-10-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	var entries []PreviewEntry
+	json.NewDecoder(response.Body).Decode(&entries)
+	if response.StatusCode != http.StatusOK || len(entries) != 1 {
+		t.Fatalf("Unexpected response %d %v", response.StatusCode, entries)
+	}
+	if entries[0].TogglEntryId != 7 || entries[0].Activity != "Meeting" || entries[0].Category != "Uncategorized" || entries[0].Hours != 1.5 {
+		t.Errorf("Unexpected entry %v", entries[0])
+	}
+}
+
+func TestReportReturnsTotals(t *testing.T) {
+	server := startServer(t, &fakeEngine{mapped: testMapped(t)}, "")
+
+	response, err := http.Get(server.URL + "/report?since=2024-09-01&until=2024-10-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	var result report.Report
+	json.NewDecoder(response.Body).Decode(&result)
+	if result.TotalHours != 1.5 || len(result.Days) != 1 {
+		t.Errorf("Unexpected report %v", result)
+	}
+}
+
+func TestSyncPassesRangeAndDryRun(t *testing.T) {
+	engine := &fakeEngine{}
+	server := startServer(t, engine, "")
+
+	response, err := http.Post(server.URL+"/sync", "application/json", strings.NewReader(`{"since":"2024-09-01","until":"2024-09-02","dry_run":true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	var result plan.Plan
+	json.NewDecoder(response.Body).Decode(&result)
+	if response.StatusCode != http.StatusOK || len(result.Operations) != 1 {
+		t.Fatalf("Unexpected response %d %v", response.StatusCode, result)
+	}
+	if !engine.syncDryRun || !engine.syncSince.Equal(testutils.DateTimeFromString("2024-09-01 00:00:00", t)) {
+		t.Errorf("Unexpected engine call since=%v dry_run=%v", engine.syncSince, engine.syncDryRun)
+	}
+}
+
+func TestRequestErrors(t *testing.T) {
+	testCases := []struct {
+		Name           string
+		Engine         *fakeEngine
+		Token          string
+		Method         string
+		Path           string
+		Body           string
+		Header         string
+		ExpectedStatus int
+	}{
+		{Name: "invalid range", Engine: &fakeEngine{}, Method: http.MethodGet, Path: "/preview?since=2024-09-01&until=2024-08-01", ExpectedStatus: http.StatusBadRequest},
+		{Name: "missing range", Engine: &fakeEngine{}, Method: http.MethodGet, Path: "/report", ExpectedStatus: http.StatusBadRequest},
+		{Name: "engine failure", Engine: &fakeEngine{err: errors.New("toggl down")}, Method: http.MethodGet, Path: "/preview?since=2024-09-01&until=2024-10-01", ExpectedStatus: http.StatusBadGateway},
+		{Name: "invalid sync range", Engine: &fakeEngine{}, Method: http.MethodPost, Path: "/sync", Body: `{"since":"2024-09-02","until":"2024-09-01"}`, ExpectedStatus: http.StatusBadRequest},
+		{Name: "policy violated", Engine: &fakeEngine{err: fmt.Errorf("%w: 2 violations", validation.ErrPolicyViolated)}, Method: http.MethodPost, Path: "/sync", Body: `{"since":"2024-09-01","until":"2024-09-02"}`, ExpectedStatus: http.StatusUnprocessableEntity},
+		{Name: "sync failure", Engine: &fakeEngine{err: errors.New("sloneek down")}, Method: http.MethodPost, Path: "/sync", Body: `{"since":"2024-09-01","until":"2024-09-02"}`, ExpectedStatus: http.StatusBadGateway},
+		{Name: "body too large", Engine: &fakeEngine{}, Method: http.MethodPost, Path: "/sync", Body: `{"since":"` + strings.Repeat("x", maxBodySize) + `"}`, ExpectedStatus: http.StatusRequestEntityTooLarge},
+		{Name: "wrong method", Engine: &fakeEngine{}, Method: http.MethodGet, Path: "/sync", ExpectedStatus: http.StatusMethodNotAllowed},
+		{Name: "missing token", Engine: &fakeEngine{}, Token: "secret", Method: http.MethodGet, Path: "/report?since=2024-09-01&until=2024-10-01", ExpectedStatus: http.StatusUnauthorized},
+		{Name: "valid token", Engine: &fakeEngine{mapped: &Mapped{}}, Token: "secret", Method: http.MethodGet, Path: "/report?since=2024-09-01&until=2024-10-01", Header: "Bearer secret", ExpectedStatus: http.StatusOK},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			server := startServer(t, testCase.Engine, testCase.Token)
+			request, _ := http.NewRequest(testCase.Method, server.URL+testCase.Path, strings.NewReader(testCase.Body))
+			if testCase.Header != "" {
+				request.Header.Set("Authorization", testCase.Header)
+			}
+
+			response, err := http.DefaultClient.Do(request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			response.Body.Close()
+
+			if response.StatusCode != testCase.ExpectedStatus {
+				t.Errorf("Expected %d, got %d", testCase.ExpectedStatus, response.StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+	"timetrack-sync/src/api"
+	"timetrack-sync/src/plan"
+	"timetrack-sync/src/utils"
+
+	"github.com/rs/zerolog"
+)
+
+// clientEngine runs the sync engine against the real Toggl and Sloneek clients.
+// Requests are serialized so two API calls never write to Sloneek at the same time, syncs
+// also hold the lock file so they do not overlap with the daemon or webhook.
+type clientEngine struct {
+	app      *app
+	account  *account
+	location *time.Location
+	logger   *zerolog.Logger
+	mutex    sync.Mutex
+	// lockPath is the lock file shared with the daemon and webhook commands
+	lockPath string
+	// allowDelete keeps delete operations in applied plans, dry runs always show them
+	allowDelete bool
+}
+
+func (engine *clientEngine) Preview(ctx context.Context, since time.Time, until time.Time) (*api.Mapped, error) {
+	engine.mutex.Lock()
+	defer engine.mutex.Unlock()
+
+	prepared, err := mapRange(engine.app, engine.account, engine.location, since, until, engine.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.Mapped{
+		Entries:    prepared.sloneekEntries,
+		Activities: prepared.sloneekActivities,
+		Categories: prepared.sloneekCategories,
+	}, nil
+}
+
+func (engine *clientEngine) Sync(ctx context.Context, since time.Time, until time.Time, dryRun bool) (*plan.Plan, error) {
+	engine.mutex.Lock()
+	defer engine.mutex.Unlock()
+
+	var syncPlan *plan.Plan
+	err := lockedSync(ctx, engine.lockPath, func() error {
+		var err error
+		syncPlan, err = engine.sync(since, until, dryRun)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return syncPlan, nil
+}
+
+func (engine *clientEngine) sync(since time.Time, until time.Time, dryRun bool) (*plan.Plan, error) {
+	prepared, err := prepareSyncRange(engine.app, engine.account, engine.location, since, until, engine.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	storedEntries, err := prepared.sloneekClient.GetTimeEntries(since, until)
+	if err != nil {
+		return nil, err
+	}
+
+	syncPlan := plan.Build(prepared.sloneekEntries, storedEntries, prepared.sloneekActivities, prepared.sloneekCategories, since, until)
+	if dryRun {
+		return syncPlan, nil
+	}
+	if !engine.allowDelete {
+		syncPlan.SkipDeletes()
+	}
+
+	err = plan.Apply(syncPlan, storedEntries, prepared.sloneekClient, engine.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return syncPlan, nil
+}
+
+func runServe(app *app, args []string) int {
+	logger := app.logger
+	flagSet := app.newFlagSet("serve", "serve [flags]")
+	bearerToken := flagSet.String("bearer", "", "Bearer token obtained after login to Sloneek app")
+	timezone := flagSet.String("timezone", "Local", "IANA timezone (e.g. Europe/Prague) used for date ranges, day boundaries and rounding.")
+	listen := flagSet.String("listen", "127.0.0.1:8081", "Address to serve the API on.")
+	allowDelete := flagSet.Bool("delete", false, "Delete synced Sloneek entries whose Toggl entry is gone when syncing through the API.")
+	lockPath := flagSet.String("lock", defaultLockPath, "Lock file preventing concurrent syncs, shared with the daemon and webhook commands.")
+	apiToken := flagSet.String("api-token", app.getenv("TIMETRACK_API_TOKEN"), "Token clients must send as a bearer token. Defaults to TIMETRACK_API_TOKEN.")
+
+	if code, ok := parseFlags(flagSet, args); !ok {
+		return code
+	}
+
+	if app.resolveBearer(bearerToken) == "" {
+		logger.Error().Msg("Sloneek JWT not found")
+		return exitUsage
+	}
+
+	location, err := utils.LoadLocation(*timezone)
+	if err != nil {
+		logger.Error().Err(err).Str("timezone", *timezone).Msg("Unknown timezone")
+		return exitUsage
+	}
+
+	app.addSecrets(*apiToken)
+	if *apiToken == "" {
+		logger.Warn().Msg("API token not set, anyone who can reach the address can trigger syncs")
+	}
+
+	apiLogger := logger.With().Str("component", "api").Logger()
+	server := &api.Server{
+		Engine:   &clientEngine{app: app, account: app.defaultAccount(*bearerToken), location: location, logger: logger, lockPath: *lockPath, allowDelete: *allowDelete},
+		Location: location,
+		Token:    *apiToken,
+		Logger:   &apiLogger,
+	}
+	httpServer := &http.Server{Addr: *listen, Handler: server.Handler(), ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, stop := signal.NotifyContext(app.ctx, syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdownCtx)
+	}()
+
+	logger.Info().Str("listen", *listen).Msg("Serving API")
+	err = httpServer.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Error().Err(err).Msg("API server failed")
+		return exitFailure
+	}
+
+	return exitOk
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"timetrack-sync/src/approval"
+	"timetrack-sync/src/credentials"
+	"timetrack-sync/src/holidays"
+	"timetrack-sync/src/logging"
+	"timetrack-sync/src/preview"
+	"timetrack-sync/src/redact"
+	"timetrack-sync/src/sloneek"
+	toggltrack "timetrack-sync/src/togglTrack"
+	"timetrack-sync/src/utils"
+
+	"github.com/joho/godotenv"
+	"github.com/rs/zerolog"
+)
+
+const (
+	SLONEEK_API   = "https://api2.sloneek.com"
+	TOGGL_API_URL = "https://api.track.toggl.com/api/v9"
+)
+
+// app holds everything a run reads from and writes to, so commands never touch the process directly.
+type app struct {
+	ctx        context.Context
+	env        map[string]string
+	stdin      io.Reader
+	stdout     io.Writer
+	stderr     io.Writer
+	now        func() time.Time
+	httpClient *http.Client
+	logger     *zerolog.Logger
+	// redactor masks secrets in every log line, known secret values are registered once they are read
+	redactor *redact.Writer
+
+	// stdinBuffer is shared by everything reading stdin, so lines one reader buffered are not
+	// lost to the next
+	stdinBuffer *bufio.Reader
+
+	// the credentials store is opened lazily so commands without credentials never prompt
+	storeOnce sync.Once
+	store     credentials.Store
+}
+
+// Option replaces a dependency of Run, used by tests.
+type Option func(*app)
+
+func WithStdin(stdin io.Reader) Option {
+	return func(app *app) { app.stdin = stdin }
+}
+
+// WithClock replaces the clock used for default date ranges and daemon windows.
+func WithClock(now func() time.Time) Option {
+	return func(app *app) { app.now = now }
+}
+
+// WithHttpClient makes the Toggl and Sloneek clients send requests through the given client.
+func WithHttpClient(httpClient *http.Client) Option {
+	return func(app *app) { app.httpClient = httpClient }
+}
+
+var secretEnvironmentVariables = []string{
+	"TOGGL_API_KEY",
+	"TOGGL_WEBHOOK_SECRET",
+	"TIMETRACK_API_TOKEN",
+	"TIMETRACK_PASSPHRASE",
+}
+
+// Run executes the command line args with env in the KEY=value form of os.Environ and returns
+// the exit code. Variables of the .env file in the working directory fill in missing ones.
+func Run(ctx context.Context, args []string, env []string, stdout io.Writer, stderr io.Writer, options ...Option) int {
+	zerolog.TimeFieldFormat = zerolog.TimeFormatUnixMs
+	logOptions, args, err := logging.ExtractFlags(args)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	logger, redactor, logCloser, err := logging.Setup(logOptions, stderr)
+	if err != nil {
+		fmt.Fprintln(stderr, "Error while opening log file:", err)
+		return exitFailure
+	}
+	defer logCloser.Close()
+
+	app := &app{
+		ctx:      ctx,
+		env:      map[string]string{},
+		stdin:    os.Stdin,
+		stdout:   stdout,
+		stderr:   stderr,
+		now:      time.Now,
+		logger:   &logger,
+		redactor: redactor,
+	}
+	for _, option := range options {
+		option(app)
+	}
+	for _, variable := range env {
+		name, value, _ := strings.Cut(variable, "=")
+		app.env[name] = value
+	}
+
+	logger.Info().Msg("Loading environment variables")
+	fileEnv, err := godotenv.Read(".env")
+	if errors.Is(err, fs.ErrNotExist) {
+		// variables may come from the environment itself, doctor reports what is missing
+		logger.Warn().Msg("No .env file found, using process environment")
+	} else if err != nil {
+		logger.Error().Err(err).Msg("Error while loading environment variables")
+		return exitFailure
+	}
+	for name, value := range fileEnv {
+		if _, ok := app.env[name]; !ok {
+			app.env[name] = value
+		}
+	}
+
+	redactor.AddFields(strings.Split(app.getenv("TIMETRACK_REDACT_FIELDS"), ",")...)
+	for _, name := range secretEnvironmentVariables {
+		redactor.AddSecret(app.getenv(name))
+	}
+
+	// flags without a command keep the original behavior of running a sync
+	if len(args) == 0 || (len(args[0]) > 0 && args[0][0] == '-' && args[0] != "-h" && args[0] != "--help") {
+		return runSync(app, args)
+	}
+
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage(app.stdout)
+		return exitOk
+	}
+
+	commandIndex := slices.IndexFunc(commands, func(command command) bool { return command.name == args[0] })
+	if commandIndex == -1 {
+		fmt.Fprintf(app.stderr, "Unknown command %q\n\n", args[0])
+		printUsage(app.stderr)
+		return exitUsage
+	}
+
+	return commands[commandIndex].run(app, args[1:])
+}
+
+func (app *app) getenv(name string) string {
+	return app.env[name]
+}
+
+// addSecrets registers values to be masked in logs.
+func (app *app) addSecrets(values ...string) {
+	app.redactor.AddSecret(values...)
+}
+
+// colored reports whether stdout is a terminal that should get colored output.
+func (app *app) colored() bool {
+	file, ok := app.stdout.(*os.File)
+	return ok && app.getenv("NO_COLOR") == "" && preview.ShouldColor(file)
+}
+
+// input returns the buffered stdin shared by secret prompts and the interactive review.
+func (app *app) input() *bufio.Reader {
+	if app.stdinBuffer == nil {
+		app.stdinBuffer = bufio.NewReader(app.stdin)
+	}
+
+	return app.stdinBuffer
+}
+
+func (app *app) newFlagSet(name string, usage string) *flag.FlagSet {
+	flagSet := newFlagSet(name, usage)
+	flagSet.SetOutput(app.stderr)
+	return flagSet
+}
+
+// account holds credentials and the project mapping of the person whose time is synced.
+type account struct {
+	name            string
+	togglApiKey     string
+	sloneekBearer   string
+	sloneekUserUuid string
+	mapping         utils.ProjectMapping
+}
+
+// defaultAccount is the single user of the environment, used when no team config is given.
+func (app *app) defaultAccount(bearerToken string) *account {
+	togglApiKey := app.getenv("TOGGL_API_KEY")
+	if togglApiKey == "" {
+		togglApiKey = app.storedCredential(credentials.TogglApiKey)
+	}
+
+	return &account{
+		togglApiKey:     togglApiKey,
+		sloneekBearer:   bearerToken,
+		sloneekUserUuid: app.getenv("USER_UUID"),
+		mapping:         utils.DefaultProjectMapping,
+	}
+}
+
+// apiUrl lets the CLI run against the mock server or another deployment.
+func (app *app) apiUrl(name string, fallback string) string {
+	if url := app.getenv(name); url != "" {
+		return url
+	}
+
+	return fallback
+}
+
+// approvalPolicy reads the policy file of TIMETRACK_APPROVAL_POLICY, TIMETRACK_AUTO_APPROVE
+// overrides its default. Without either nothing is approved automatically.
+func (app *app) approvalPolicy() (*approval.Policy, error) {
+	policy := &approval.Policy{}
+	if path := app.getenv("TIMETRACK_APPROVAL_POLICY"); path != "" {
+		var err error
+		policy, err = approval.Load(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if value := app.getenv("TIMETRACK_AUTO_APPROVE"); value != "" {
+		autoApprove, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TIMETRACK_AUTO_APPROVE %q: %w", value, err)
+		}
+		policy.Default = autoApprove
+	}
+
+	return policy, nil
+}
+
+// holidays creates the calendar of TIMETRACK_HOLIDAYS (CZ by default, none disables built-in
+// holidays) extended by comma separated .json or .ics files of TIMETRACK_HOLIDAY_FILES.
+func (app *app) holidays() (*holidays.Calendar, error) {
+	country := app.getenv("TIMETRACK_HOLIDAYS")
+	if country == "" {
+		country = holidays.CountryCzech
+	}
+
+	calendar, err := holidays.CreateCalendar(country)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range strings.Split(app.getenv("TIMETRACK_HOLIDAY_FILES"), ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		err = calendar.LoadFile(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return calendar, nil
+}
+
+// sloneekClient and togglClient create clients of the account with their own sub-loggers.
+func (app *app) sloneekClient(account *account, logger *zerolog.Logger) *sloneek.SloneekClient {
+	app.addSecrets(account.sloneekBearer)
+	sloneekLogger := logger.With().Str("client", "sloneek").Logger()
+	client := sloneek.CreateSloneekClient(app.apiUrl("SLONEEK_API", SLONEEK_API), account.sloneekBearer, &sloneekLogger)
+	client.SetUserUuid(account.sloneekUserUuid)
+	if app.httpClient != nil {
+		client.SetHttpClient(app.httpClient)
+	}
+	return client
+}
+
+func (app *app) togglClient(account *account, logger *zerolog.Logger) *toggltrack.TogglTrackClient {
+	app.addSecrets(account.togglApiKey)
+	togglLogger := logger.With().Str("client", "toggl").Logger()
+	client := toggltrack.CreateTogglTrackClient(app.apiUrl("TOGGL_API_URL", TOGGL_API_URL), account.togglApiKey, &togglLogger)
+	if app.httpClient != nil {
+		client.SetHttpClient(app.httpClient)
+	}
+	return client
+}
@@ -0,0 +1,123 @@
+package approval
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+	"timetrack-sync/src/sloneek"
+)
+
+// Policy decides which saved entries Sloneek approves automatically. Activities and categories
+// are keyed by their Sloneek names, a category setting wins over the activity one and both win
+// over Default.
+type Policy struct {
+	Default    bool            `json:"default"`
+	Activities map[string]bool `json:"activities,omitempty"`
+	Categories map[string]bool `json:"categories,omitempty"`
+}
+
+func Load(path string) (*Policy, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var policy Policy
+	err = json.Unmarshal(content, &policy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid approval policy %s: %w", path, err)
+	}
+
+	return &policy, nil
+}
+
+// AutoApprove reports the setting for an entry, categoryName is empty for uncategorized entries.
+func (policy *Policy) AutoApprove(activityName string, categoryName string) bool {
+	if categoryName != "" {
+		if value, ok := policy.Categories[categoryName]; ok {
+			return value
+		}
+	}
+	if value, ok := policy.Activities[activityName]; ok {
+		return value
+	}
+
+	return policy.Default
+}
+
+// Apply sets AutoApprove of every entry by the policy.
+func (policy *Policy) Apply(entries []sloneek.TimeEntry, activities []sloneek.Activity, categories []sloneek.Category) {
+	for i := range entries {
+		categoryName := ""
+		if entries[i].CategoryId != nil {
+			categoryName = sloneek.CategoryName(categories, entries[i].CategoryId)
+		}
+
+		entries[i].AutoApprove = policy.AutoApprove(sloneek.ActivityName(activities, entries[i].ActivityId), categoryName)
+	}
+}
+
+// StatusUnknown groups entries Sloneek reported no approval status for.
+const StatusUnknown = "unknown"
+
+func status(entry *sloneek.TimeEntry) string {
+	if entry.ApprovalStatus == "" {
+		return StatusUnknown
+	}
+
+	return entry.ApprovalStatus
+}
+
+// Filter keeps entries with the given approval status, an empty status keeps all of them.
+func Filter(entries []sloneek.TimeEntry, approvalStatus string) []sloneek.TimeEntry {
+	if approvalStatus == "" {
+		return entries
+	}
+
+	return slices.DeleteFunc(slices.Clone(entries), func(entry sloneek.TimeEntry) bool { return status(&entry) != approvalStatus })
+}
+
+// Render lists entries with their approval status followed by hours and entry counts per status.
+func Render(entries []sloneek.TimeEntry, activities []sloneek.Activity, categories []sloneek.Category, location *time.Location, writer io.Writer) error {
+	sorted := slices.Clone(entries)
+	slices.SortFunc(sorted, func(a, b sloneek.TimeEntry) int { return a.Since.Compare(b.Since) })
+
+	tableWriter := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tableWriter, "Day\tTime\tActivity\tCategory\tHours\tStatus\t")
+
+	statuses := []string{}
+	hours := map[string]float64{}
+	counts := map[string]int{}
+	for _, entry := range sorted {
+		entryStatus := status(&entry)
+		if !slices.Contains(statuses, entryStatus) {
+			statuses = append(statuses, entryStatus)
+		}
+		hours[entryStatus] += entry.GetHours()
+		counts[entryStatus]++
+
+		since, until := entry.Since.In(location), entry.Until.In(location)
+		fmt.Fprintf(tableWriter, "%s\t%s-%s\t%s\t%s\t%.2f\t%s\t\n",
+			since.Format(time.DateOnly),
+			since.Format("15:04"),
+			until.Format("15:04"),
+			sloneek.ActivityName(activities, entry.ActivityId),
+			sloneek.CategoryName(categories, entry.CategoryId),
+			entry.GetHours(),
+			entryStatus,
+		)
+	}
+
+	fmt.Fprintln(tableWriter, "\t\t\t\t\t\t")
+	fmt.Fprintln(tableWriter, "Status\tEntries\tHours\t\t\t\t")
+	slices.Sort(statuses)
+	for _, entryStatus := range statuses {
+		fmt.Fprintf(tableWriter, "%s\t%d\t%.2f\t\t\t\t\n", entryStatus, counts[entryStatus], hours[entryStatus])
+	}
+
+	return tableWriter.Flush()
+}
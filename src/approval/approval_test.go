@@ -0,0 +1,103 @@
+package approval
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"timetrack-sync/src/sloneek"
+)
+
+var (
+	activities = []sloneek.Activity{{Id: "a1", Name: "Vývoj"}, {Id: "a2", Name: "Meeting"}}
+	categories = []sloneek.Category{{Id: "c1", Name: "Proteus"}, {Id: "c2", Name: "Portál"}}
+)
+
+func TestPolicyPrecedence(t *testing.T) {
+	policy := &Policy{
+		Default:    true,
+		Activities: map[string]bool{"Vývoj": false},
+		Categories: map[string]bool{"Proteus": true},
+	}
+
+	tests := []struct {
+		activity string
+		category string
+		expected bool
+	}{
+		{"Meeting", "", true},
+		{"Vývoj", "", false},
+		{"Vývoj", "Portál", false},
+		{"Vývoj", "Proteus", true},
+	}
+
+	for _, test := range tests {
+		actual := policy.AutoApprove(test.activity, test.category)
+		if actual != test.expected {
+			t.Errorf("%s/%s: expected %v, got %v", test.activity, test.category, test.expected, actual)
+		}
+	}
+}
+
+func TestApplySetsEntries(t *testing.T) {
+	category := "c1"
+	entries := []sloneek.TimeEntry{{ActivityId: "a1", CategoryId: &category}, {ActivityId: "a1"}, {ActivityId: "a2"}}
+	policy := &Policy{Categories: map[string]bool{"Proteus": true}, Activities: map[string]bool{"Meeting": true}}
+
+	policy.Apply(entries, activities, categories)
+
+	if !entries[0].AutoApprove || entries[1].AutoApprove || !entries[2].AutoApprove {
+		t.Errorf("unexpected approval flags %+v", entries)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "approval.json")
+	os.WriteFile(path, []byte(`{"default": true, "activities": {"Hiring": false}}`), 0600)
+
+	policy, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !policy.Default || policy.AutoApprove("Hiring", "") {
+		t.Errorf("unexpected policy %+v", policy)
+	}
+
+	os.WriteFile(path, []byte(`{"default": "yes"}`), 0600)
+	_, err = Load(path)
+	if err == nil {
+		t.Error("expected an error for invalid policy")
+	}
+}
+
+func TestRenderAndFilter(t *testing.T) {
+	day := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+	category := "c1"
+	entries := []sloneek.TimeEntry{
+		{ActivityId: "a2", Since: day.Add(4 * time.Hour), Until: day.Add(5 * time.Hour), ApprovalStatus: "pending"},
+		{ActivityId: "a1", CategoryId: &category, Since: day, Until: day.Add(3 * time.Hour), ApprovalStatus: "approved"},
+		{ActivityId: "a1", Since: day.Add(24 * time.Hour), Until: day.Add(26 * time.Hour)},
+	}
+
+	output := &strings.Builder{}
+	err := Render(entries, activities, categories, time.UTC, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(output.String(), "\n")
+	if !strings.HasPrefix(lines[1], "2024-03-04  08:00-11:00  Vývoj") || !strings.Contains(lines[1], "approved") {
+		t.Errorf("expected entries sorted by time, got %q", lines[1])
+	}
+	for _, expected := range []string{"approved    1            3.00", "pending     1            1.00", "unknown     1            2.00"} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("missing total %q in:\n%s", expected, output.String())
+		}
+	}
+
+	pending := Filter(entries, "pending")
+	if len(pending) != 1 || pending[0].ActivityId != "a2" || len(entries) != 3 {
+		t.Errorf("unexpected filtered entries %+v", pending)
+	}
+}
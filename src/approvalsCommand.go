@@ -0,0 +1,64 @@
+package main
+
+import (
+	"slices"
+	"timetrack-sync/src/approval"
+	"timetrack-sync/src/sloneek"
+)
+
+func runApprovals(app *app, args []string) int {
+	logger := app.logger
+	flagSet := app.newFlagSet("approvals", "approvals [flags]")
+	flags := registerCommonFlags(flagSet, app.now())
+	userUuid := flagSet.String("user-uuid", "", "Sloneek user whose entries are shown, requires approver rights. Defaults to USER_UUID.")
+	approvalStatus := flagSet.String("status", "", "Show only entries with this approval status, e.g. pending.")
+	all := flagSet.Bool("all", false, "Show entries entered in Sloneek too, not only the ones synced from Toggl.")
+
+	if code, ok := parseFlags(flagSet, args); !ok {
+		return code
+	}
+
+	if app.resolveBearer(flags.bearerToken) == "" {
+		logger.Error().Msg("Sloneek JWT not found")
+		return exitUsage
+	}
+
+	location, since, until, err := resolveRange(flags)
+	if err != nil {
+		logger.Error().Err(err).Msg("Invalid range")
+		return exitUsage
+	}
+
+	account := app.defaultAccount(*flags.bearerToken)
+	if *userUuid != "" {
+		account.sloneekUserUuid = *userUuid
+	}
+	sloneekClient := app.sloneekClient(account, logger)
+
+	activities, err := sloneekClient.GetActivities()
+	if err != nil {
+		logger.Error().Err(err).Msg("Error while fetching Sloneek activities")
+		return exitFailure
+	}
+	categories, err := sloneekClient.GetCategories()
+	if err != nil {
+		logger.Error().Err(err).Msg("Error while fetching Sloneek categories")
+		return exitFailure
+	}
+	entries, err := sloneekClient.GetTimeEntries(since, until)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error while fetching Sloneek entries")
+		return exitFailure
+	}
+	if !*all {
+		entries = slices.DeleteFunc(entries, func(entry sloneek.TimeEntry) bool { return entry.SourceId == 0 })
+	}
+
+	err = approval.Render(approval.Filter(entries, *approvalStatus), activities, categories, location, app.stdout)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error while rendering approvals")
+		return exitFailure
+	}
+
+	return exitOk
+}
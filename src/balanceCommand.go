@@ -0,0 +1,110 @@
+package main
+
+import (
+	"io"
+	"os"
+	"timetrack-sync/src/contract"
+	"timetrack-sync/src/report"
+	"timetrack-sync/src/sloneek"
+)
+
+const (
+	balanceSourceSloneek = "sloneek"
+	balanceSourceToggl   = "toggl"
+)
+
+// contract reads the contract file of TIMETRACK_CONTRACT, without it a full-time contract is expected.
+func (app *app) contract() (*contract.Contract, error) {
+	path := app.getenv("TIMETRACK_CONTRACT")
+	if path == "" {
+		fullTime := contract.DefaultContract()
+		return &fullTime, nil
+	}
+
+	return contract.Load(path)
+}
+
+func runBalance(app *app, args []string) int {
+	logger := app.logger
+	flagSet := app.newFlagSet("balance", "balance [flags]")
+	flags := registerCommonFlags(flagSet, app.now())
+	reportSettings := registerReportFlags(flagSet)
+	source := flagSet.String("source", balanceSourceSloneek, "Hours compared with the contract: sloneek (stored entries) or toggl (mapped entries not synced yet).")
+	openingBalance := flagSet.Float64("opening-balance", 0, "Balance carried over from before the range in hours, negative for a deficit.")
+
+	if code, ok := parseFlags(flagSet, args); !ok {
+		return code
+	}
+
+	if *source != balanceSourceSloneek && *source != balanceSourceToggl {
+		logger.Error().Str("source", *source).Msg("Unknown source, expected sloneek or toggl")
+		return exitUsage
+	}
+
+	format, err := report.ParseFormat(*reportSettings.format)
+	if err != nil {
+		logger.Error().Err(err).Msg("Invalid report format")
+		return exitUsage
+	}
+
+	if app.resolveBearer(flags.bearerToken) == "" {
+		logger.Error().Msg("Sloneek JWT not found")
+		return exitUsage
+	}
+
+	location, since, until, err := resolveRange(flags)
+	if err != nil {
+		logger.Error().Err(err).Msg("Invalid range")
+		return exitUsage
+	}
+
+	workContract, err := app.contract()
+	if err != nil {
+		logger.Error().Err(err).Msg("Invalid contract")
+		return exitUsage
+	}
+
+	calendar, err := app.holidays()
+	if err != nil {
+		logger.Error().Err(err).Msg("Invalid holiday calendar")
+		return exitUsage
+	}
+
+	account := app.defaultAccount(*flags.bearerToken)
+	var entries []sloneek.TimeEntry
+	if *source == balanceSourceToggl {
+		prepared, err := mapRange(app, account, location, since, until, logger)
+		if err != nil {
+			logger.Error().Err(err).Msg("Error while preparing sync")
+			return exitFailure
+		}
+		entries = prepared.sloneekEntries
+	} else {
+		entries, err = app.sloneekClient(account, logger).GetTimeEntries(since, until)
+		if err != nil {
+			logger.Error().Err(err).Msg("Error while looking up stored entries")
+			return exitFailure
+		}
+	}
+
+	balance := contract.Compare(entries, workContract, calendar, *openingBalance, since, until, app.now(), location)
+	reportWriter := io.Writer(app.stdout)
+	if *reportSettings.output != "" {
+		reportFile, err := os.Create(*reportSettings.output)
+		if err != nil {
+			logger.Error().Err(err).Str("path", *reportSettings.output).Msg("Error while creating report file")
+			return exitFailure
+		}
+		defer reportFile.Close()
+
+		reportWriter = reportFile
+	}
+
+	err = contract.Render(balance, format, reportWriter)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error while rendering balance")
+		return exitFailure
+	}
+
+	return exitOk
+}
@@ -0,0 +1,85 @@
+// Command mockserver serves fake Toggl and Sloneek APIs backed by a JSON fixture, so the CLI
+// can be run end-to-end without real accounts:
+//
+//	go run ./src/cmd/mockserver -fixture src/cmd/mockserver/fixture.example.json
+//	SLONEEK_API=http://127.0.0.1:8090 TOGGL_API_URL=http://127.0.0.1:8090/api/v9 TOGGL_API_KEY=mock-api-key \
+//		go run ./src -bearer mock-bearer -dry-run
+//
+// State is kept in memory only, restarting the server resets it to the fixture.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"net/http"
+	"os"
+	"time"
+	"timetrack-sync/src/fakeapi"
+
+	"github.com/rs/zerolog"
+)
+
+type fixture struct {
+	Toggl   fakeapi.TogglState   `json:"toggl"`
+	Sloneek fakeapi.SloneekState `json:"sloneek"`
+}
+
+func loadFixture(path string) (*fixture, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var loaded fixture
+	err = json.Unmarshal(content, &loaded)
+	if err != nil {
+		return nil, err
+	}
+
+	return &loaded, nil
+}
+
+// logRequests logs every request with the status it was answered with.
+func logRequests(handler http.Handler, logger *zerolog.Logger) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: writer, status: http.StatusOK}
+		started := time.Now()
+		handler.ServeHTTP(recorder, request)
+		logger.Info().Str("method", request.Method).Str("path", request.URL.Path).Int("status", recorder.status).Dur("duration", time.Since(started)).Msg("Request served")
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (recorder *statusRecorder) WriteHeader(status int) {
+	recorder.status = status
+	recorder.ResponseWriter.WriteHeader(status)
+}
+
+func main() {
+	output := os.Stderr
+	logger := zerolog.New(output).With().Timestamp().Logger().Output(zerolog.ConsoleWriter{Out: output, TimeFormat: time.StampMilli})
+
+	fixturePath := flag.String("fixture", "src/cmd/mockserver/fixture.example.json", "JSON file with the served Toggl and Sloneek data.")
+	listen := flag.String("listen", "127.0.0.1:8090", "Address to listen on.")
+	flag.Parse()
+
+	loaded, err := loadFixture(*fixturePath)
+	if err != nil {
+		logger.Fatal().Err(err).Str("fixture", *fixturePath).Msg("Error while loading fixture")
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(fakeapi.TogglPrefix+"/", fakeapi.CreateToggl(loaded.Toggl))
+	mux.Handle("/v2/", fakeapi.CreateSloneek(loaded.Sloneek))
+
+	logger.Info().Str("listen", *listen).Str("toggl_api_url", "http://"+*listen+fakeapi.TogglPrefix).Str("sloneek_api", "http://"+*listen).Msg("Serving mock APIs")
+	server := &http.Server{Addr: *listen, Handler: logRequests(mux, &logger), ReadHeaderTimeout: 10 * time.Second}
+	err = server.ListenAndServe()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Mock server failed")
+	}
+}
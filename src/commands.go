@@ -0,0 +1,713 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+	"timetrack-sync/src/plan"
+	"timetrack-sync/src/preview"
+	"timetrack-sync/src/reconcile"
+	"timetrack-sync/src/report"
+	"timetrack-sync/src/review"
+	"timetrack-sync/src/sloneek"
+	"timetrack-sync/src/team"
+	toggltrack "timetrack-sync/src/togglTrack"
+	"timetrack-sync/src/utils"
+	"timetrack-sync/src/validation"
+
+	"github.com/rs/zerolog"
+)
+
+func newFlagSet(name string, usage string) *flag.FlagSet {
+	flagSet := flag.NewFlagSet(name, flag.ContinueOnError)
+	flagSet.Usage = func() {
+		fmt.Fprintf(flagSet.Output(), "Usage: timetrack-sync %s\n\nFlags:\n", usage)
+		flagSet.PrintDefaults()
+	}
+
+	return flagSet
+}
+
+// parseFlags returns the exit code to finish with when parsing did not succeed.
+func parseFlags(flagSet *flag.FlagSet, args []string) (int, bool) {
+	err := flagSet.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOk, false
+	}
+	if err != nil {
+		return exitUsage, false
+	}
+
+	return exitOk, true
+}
+
+// commonFlags are shared by all commands talking to both Toggl and Sloneek.
+type commonFlags struct {
+	bearerToken *string
+	timezone    *string
+	since       *string
+	until       *string
+	// now defaults the range to its month in the timezone
+	now time.Time
+}
+
+// registerCommonFlags defaults the range to the month of now, see resolveRange.
+func registerCommonFlags(flagSet *flag.FlagSet, now time.Time) *commonFlags {
+	flags := registerRangeFlags(flagSet, now)
+	flags.bearerToken = flagSet.String("bearer", "", "Bearer token obtained after login to Sloneek app")
+
+	return flags
+}
+
+// registerRangeFlags registers the range without the Sloneek bearer, for commands that read
+// Toggl only. The bearer token of the returned flags stays nil.
+func registerRangeFlags(flagSet *flag.FlagSet, now time.Time) *commonFlags {
+	return &commonFlags{
+		timezone: flagSet.String("timezone", "Local", "IANA timezone (e.g. Europe/Prague) used for date ranges, day boundaries and rounding."),
+		since:    flagSet.String("since", "", "First day of the synced range (inclusive). Defaults to the first day of the current month."),
+		until:    flagSet.String("until", "", "Last day of the synced range (exclusive). Defaults to the first day of the next month."),
+		now:      now,
+	}
+}
+
+// syncContext holds everything resolved from the common flags and fetched from both services.
+type syncContext struct {
+	location          *time.Location
+	since             time.Time
+	until             time.Time
+	sloneekClient     *sloneek.SloneekClient
+	sloneekActivities []sloneek.Activity
+	sloneekCategories []sloneek.Category
+	sloneekEntries    []sloneek.TimeEntry
+	// togglOriginals are Toggl entries before rounding and splitting, keyed by ID
+	togglOriginals map[int64]toggltrack.TimeEntry
+}
+
+// prepareSync maps the range of the flags and checks the working time policy before anything is saved.
+func prepareSync(app *app, flags *commonFlags, logger *zerolog.Logger) (*syncContext, error) {
+	prepared, err := mapFlags(app, flags, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	err = app.checkPolicy(prepared, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return prepared, nil
+}
+
+// mapFlags maps the range of the flags without the policy check, commands that only read use it.
+func mapFlags(app *app, flags *commonFlags, logger *zerolog.Logger) (*syncContext, error) {
+	if app.resolveBearer(flags.bearerToken) == "" {
+		return nil, errors.New("Sloneek JWT not found")
+	}
+
+	location, since, until, err := resolveRange(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapRange(app, app.defaultAccount(*flags.bearerToken), location, since, until, logger)
+}
+
+func resolveRange(flags *commonFlags) (*time.Location, time.Time, time.Time, error) {
+	location, err := utils.LoadLocation(*flags.timezone)
+	if err != nil {
+		return nil, time.Time{}, time.Time{}, fmt.Errorf("Unknown timezone %q: %w", *flags.timezone, err)
+	}
+
+	since, until, err := flags.rangeIn(location)
+	if err != nil {
+		return nil, time.Time{}, time.Time{}, err
+	}
+
+	return location, since, until, nil
+}
+
+// rangeIn parses the range in the location. Omitted days default to the month of now in the
+// location, so the month does not depend on the timezone of the machine.
+func (flags *commonFlags) rangeIn(location *time.Location) (time.Time, time.Time, error) {
+	local := flags.now.In(location)
+	since := time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, location)
+	until := since.AddDate(0, 1, 0)
+
+	var err error
+	if *flags.since != "" {
+		since, err = time.ParseInLocation(time.DateOnly, *flags.since, location)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("Error while parsing interval start: %w", err)
+		}
+	}
+
+	if *flags.until != "" {
+		until, err = time.ParseInLocation(time.DateOnly, *flags.until, location)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("Error while parsing interval end: %w", err)
+		}
+	}
+
+	return since, until, nil
+}
+
+// prepareSyncRange maps the range and checks the working time policy before anything is saved.
+func prepareSyncRange(app *app, account *account, location *time.Location, since time.Time, until time.Time, logger *zerolog.Logger) (*syncContext, error) {
+	prepared, err := mapRange(app, account, location, since, until, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	err = app.checkPolicy(prepared, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return prepared, nil
+}
+
+// mapRange fetches Toggl entries of the range and maps them to Sloneek entries.
+func mapRange(app *app, account *account, location *time.Location, since time.Time, until time.Time, logger *zerolog.Logger) (*syncContext, error) {
+	togglTrackClient := app.togglClient(account, logger)
+	// entries started the day before may cross midnight into the range
+	togglTimeEntries, err := togglTrackClient.GetTimeEntries(since.AddDate(0, 0, -1), until)
+	if err != nil {
+		return nil, err
+	}
+
+	// a running timer has no stop yet, it is synced once stopped
+	togglTimeEntries = slices.DeleteFunc(togglTimeEntries, func(entry toggltrack.TimeEntry) bool {
+		running := entry.Stop.IsZero() || entry.Duration < 0
+		if running {
+			logger.Info().Int64("toggl_entry", entry.ID).Msg("Skipping running time entry")
+		}
+		return running
+	})
+
+	togglOriginals := make(map[int64]toggltrack.TimeEntry, len(togglTimeEntries))
+	for _, entry := range togglTimeEntries {
+		togglOriginals[entry.ID] = entry
+	}
+
+	logger.Info().Msg("Rounding time entries")
+	roundedEntries := RoundTimeEntries(togglTimeEntries, location)
+
+	logger.Info().Msg("Splitting time entries crossing midnight")
+	roundedEntries = utils.SplitTimeEntriesAtMidnight(roundedEntries, location)
+	roundedEntries = slices.DeleteFunc(roundedEntries, func(entry toggltrack.TimeEntry) bool {
+		return entry.Start.Before(since) || !entry.Start.Before(until)
+	})
+	togglProjects, err := togglTrackClient.GetProjects()
+	if err != nil {
+		return nil, err
+	}
+
+	sloneekClient := app.sloneekClient(account, logger)
+	sloneekCategories, err := sloneekClient.GetCategories()
+	if err != nil {
+		return nil, err
+	}
+
+	sloneekActivities, err := sloneekClient.GetActivities()
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info().Msg("Mapping Toggl time entries to Sloneek time entries")
+	sloneekEntries := []sloneek.TimeEntry{}
+	for _, entry := range roundedEntries {
+		sloneekEntry, err := utils.MapTogglEntryToSloneekEntryWithMapping(&entry, account.mapping, togglProjects, sloneekActivities, sloneekCategories, logger)
+		if err != nil {
+			logger.Error().Err(err).Msg("Error while mapping toggle entry to sloneek entry")
+			return nil, err
+		}
+
+		logger.Debug().Any("sloneek_entry", sloneekEntry).Any("toggl_entry", entry).Msg("Entry mapped.")
+		sloneekEntries = append(sloneekEntries, *sloneekEntry)
+	}
+
+	policy, err := app.approvalPolicy()
+	if err != nil {
+		return nil, err
+	}
+	policy.Apply(sloneekEntries, sloneekActivities, sloneekCategories)
+
+	logger.Debug().Any("result", sloneekEntries).Msg("Mam vysledek")
+
+	return &syncContext{
+		location:          location,
+		since:             since,
+		until:             until,
+		sloneekClient:     sloneekClient,
+		sloneekActivities: sloneekActivities,
+		sloneekCategories: sloneekCategories,
+		sloneekEntries:    sloneekEntries,
+		togglOriginals:    togglOriginals,
+	}, nil
+}
+
+// reportFlags configure how the summary report is rendered.
+type reportFlags struct {
+	format *string
+	output *string
+}
+
+func registerReportFlags(flagSet *flag.FlagSet) *reportFlags {
+	return &reportFlags{
+		format: flagSet.String("report-format", string(report.FormatText), "Format of the summary report: text, csv, json or markdown."),
+		output: flagSet.String("report-output", "", "File to write the summary report to. Defaults to stdout."),
+	}
+}
+
+func writeReport(app *app, prepared *syncContext, flags *reportFlags, logger *zerolog.Logger) int {
+	format, err := report.ParseFormat(*flags.format)
+	if err != nil {
+		logger.Error().Err(err).Msg("Invalid report format")
+		return exitUsage
+	}
+
+	summary := report.Build(prepared.sloneekEntries, prepared.sloneekActivities, prepared.sloneekCategories, prepared.location)
+	reportWriter := io.Writer(app.stdout)
+	if *flags.output != "" {
+		reportFile, err := os.Create(*flags.output)
+		if err != nil {
+			logger.Error().Err(err).Str("path", *flags.output).Msg("Error while creating report file")
+			return exitFailure
+		}
+		defer reportFile.Close()
+
+		reportWriter = reportFile
+	}
+
+	err = report.Render(summary, format, reportWriter)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error while rendering report")
+		return exitFailure
+	}
+
+	return exitOk
+}
+
+func runSync(app *app, args []string) int {
+	logger := app.logger
+	flagSet := app.newFlagSet("sync", "sync [flags]")
+	flags := registerCommonFlags(flagSet, app.now())
+	reportSettings := registerReportFlags(flagSet)
+	dryRun := flagSet.Bool("dry-run", false, "Whether or not to launch a dry run which does not persist any data.")
+	interactive := flagSet.Bool("interactive", false, "Review every entry in the terminal before anything is sent.")
+	planPath := flagSet.String("plan", "", "Write the planned Sloneek changes to this file instead of saving them. Execute it with 'apply'.")
+	allUsers := flagSet.Bool("all-users", false, "Sync every user of the team config, each with their own credentials.")
+	teamPath := flagSet.String("team", "team.json", "Team config used with -all-users.")
+
+	logger.Info().Msg("Parsing CLI flags")
+	if code, ok := parseFlags(flagSet, args); !ok {
+		return code
+	}
+
+	if _, err := report.ParseFormat(*reportSettings.format); err != nil {
+		logger.Error().Err(err).Msg("Invalid report format")
+		return exitUsage
+	}
+
+	if *allUsers {
+		if *interactive || *planPath != "" {
+			logger.Error().Msg("-all-users cannot be combined with -interactive or -plan")
+			return exitUsage
+		}
+
+		return syncTeam(app, *teamPath, flags, reportSettings, *dryRun, logger)
+	}
+
+	// the policy is checked once the review is done, so it sees edited entries
+	prepared, err := mapFlags(app, flags, logger)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error while preparing sync")
+		return exitFailure
+	}
+
+	if *interactive {
+		reviewer := &review.Reviewer{
+			In:         app.input(),
+			Out:        app.stdout,
+			Activities: prepared.sloneekActivities,
+			Categories: prepared.sloneekCategories,
+			Location:   prepared.location,
+		}
+		reviewed, err := reviewer.Review(prepared.sloneekEntries)
+		if err != nil {
+			logger.Error().Err(err).Msg("Interactive review did not finish, nothing was sent")
+			return exitFailure
+		}
+
+		prepared.sloneekEntries = reviewed
+	}
+
+	if !*dryRun || *planPath != "" {
+		err = app.checkPolicy(prepared, logger)
+		if errors.Is(err, validation.ErrPolicyViolated) {
+			logger.Error().Err(err).Msg("Nothing was sent, fix the entries or allow them in the policy")
+			return exitProblemsFound
+		}
+		if err != nil {
+			logger.Error().Err(err).Msg("Error while checking the working time policy")
+			return exitFailure
+		}
+	}
+
+	if *planPath != "" {
+		storedEntries, err := prepared.sloneekClient.GetTimeEntries(prepared.since, prepared.until)
+		if err != nil {
+			logger.Error().Err(err).Msg("Error while looking up stored entries")
+			return exitFailure
+		}
+
+		syncPlan := plan.Build(prepared.sloneekEntries, storedEntries, prepared.sloneekActivities, prepared.sloneekCategories, prepared.since, prepared.until)
+		err = plan.Write(syncPlan, *planPath)
+		if err != nil {
+			logger.Error().Err(err).Str("path", *planPath).Msg("Error while writing plan")
+			return exitFailure
+		}
+
+		logger.Info().Str("path", *planPath).Int("operations", len(syncPlan.Operations)).Msg("Plan written")
+		return writeReport(app, prepared, reportSettings, logger)
+	}
+
+	if *dryRun {
+		printer := &preview.Printer{
+			Activities: prepared.sloneekActivities,
+			Categories: prepared.sloneekCategories,
+			Originals:  prepared.togglOriginals,
+			Location:   prepared.location,
+			Colored:    app.colored(),
+		}
+		err := printer.Render(prepared.sloneekEntries, app.stdout)
+		if err != nil {
+			logger.Error().Err(err).Msg("Error while rendering preview")
+			return exitFailure
+		}
+
+		// a dry run only shows what a sync would be refused for
+		violations, _, err := app.policyViolations(prepared)
+		if err != nil {
+			logger.Error().Err(err).Msg("Error while checking the working time policy")
+			return exitFailure
+		}
+		if len(violations) > 0 {
+			fmt.Fprintln(app.stdout)
+			err = validation.Render(violations, app.stdout)
+			if err != nil {
+				logger.Error().Err(err).Msg("Error while rendering violations")
+				return exitFailure
+			}
+		}
+	}
+
+	exitCode := exitOk
+	if !*dryRun {
+		logger.Info().Msg("Sending time entries to Sloneek")
+		// entries skipped in the review are missing on purpose, their synced copies are kept
+		err := applyRange(prepared, !*interactive, logger)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to apply changes to Sloneek")
+			exitCode = exitFailure
+		}
+	}
+
+	if code := writeReport(app, prepared, reportSettings, logger); code != exitOk {
+		return code
+	}
+
+	return exitCode
+}
+
+func runApply(app *app, args []string) int {
+	logger := app.logger
+	flagSet := app.newFlagSet("apply", "apply [flags] <plan.json>")
+	bearerToken := flagSet.String("bearer", "", "Bearer token obtained after login to Sloneek app")
+
+	if code, ok := parseFlags(flagSet, args); !ok {
+		return code
+	}
+
+	if flagSet.NArg() != 1 {
+		flagSet.Usage()
+		return exitUsage
+	}
+
+	if app.resolveBearer(bearerToken) == "" {
+		logger.Error().Msg("Sloneek JWT not found")
+		return exitUsage
+	}
+
+	syncPlan, err := plan.Read(flagSet.Arg(0))
+	if err != nil {
+		logger.Error().Err(err).Str("path", flagSet.Arg(0)).Msg("Error while reading plan")
+		return exitFailure
+	}
+
+	sloneekClient := app.sloneekClient(app.defaultAccount(*bearerToken), logger)
+	currentEntries, err := sloneekClient.GetTimeEntries(syncPlan.Since, syncPlan.Until)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error while looking up stored entries")
+		return exitFailure
+	}
+
+	err = plan.Apply(syncPlan, currentEntries, sloneekClient, logger)
+	if errors.Is(err, plan.ErrStateChanged) {
+		logger.Error().Msg("Refusing to apply a stale plan, create a new one with 'sync -plan'")
+		return exitProblemsFound
+	}
+	if err != nil {
+		return exitFailure
+	}
+
+	return exitOk
+}
+
+// syncTeam syncs every user of the team config in isolation, a failure of one user does not
+// stop the others. Unlike a single user sync it diffs against stored entries, so a lead can
+// re-run it for the whole team without creating duplicates.
+func syncTeam(app *app, teamPath string, flags *commonFlags, reportSettings *reportFlags, dryRun bool, logger *zerolog.Logger) int {
+	config, err := team.Load(teamPath)
+	if err != nil {
+		logger.Error().Err(err).Str("path", teamPath).Msg("Error while loading team config")
+		return exitUsage
+	}
+
+	location, since, until, err := resolveRange(flags)
+	if err != nil {
+		logger.Error().Err(err).Msg("Invalid range")
+		return exitUsage
+	}
+
+	exitCode := exitOk
+	userReports := []report.UserReport{}
+	for i := range config.Users {
+		user := &config.Users[i]
+		userLogger := logger.With().Str("user", user.Name).Logger()
+		userAccount := &account{
+			name:            user.Name,
+			togglApiKey:     user.TogglApiKey,
+			sloneekBearer:   user.SloneekBearer,
+			sloneekUserUuid: user.SloneekUserUuid,
+			mapping:         config.MappingFor(user),
+		}
+
+		userReport := report.UserReport{Name: user.Name}
+		prepared, err := prepareSyncRange(app, userAccount, location, since, until, &userLogger)
+		if err == nil {
+			userReport.Report = report.Build(prepared.sloneekEntries, prepared.sloneekActivities, prepared.sloneekCategories, location)
+			if !dryRun {
+				err = applyRange(prepared, true, &userLogger)
+			}
+		}
+
+		if err != nil {
+			userLogger.Error().Err(err).Msg("Sync of user failed")
+			userReport.Error = err.Error()
+			exitCode = exitFailure
+		}
+		userReports = append(userReports, userReport)
+	}
+
+	format, _ := report.ParseFormat(*reportSettings.format)
+	reportWriter := io.Writer(app.stdout)
+	if *reportSettings.output != "" {
+		reportFile, err := os.Create(*reportSettings.output)
+		if err != nil {
+			logger.Error().Err(err).Str("path", *reportSettings.output).Msg("Error while creating report file")
+			return exitFailure
+		}
+		defer reportFile.Close()
+
+		reportWriter = reportFile
+	}
+
+	err = report.RenderTeam(userReports, format, reportWriter)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error while rendering report")
+		return exitFailure
+	}
+
+	return exitCode
+}
+
+func runReport(app *app, args []string) int {
+	logger := app.logger
+	flagSet := app.newFlagSet("report", "report [flags]")
+	flags := registerCommonFlags(flagSet, app.now())
+	reportSettings := registerReportFlags(flagSet)
+
+	if code, ok := parseFlags(flagSet, args); !ok {
+		return code
+	}
+
+	prepared, err := mapFlags(app, flags, logger)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error while mapping Toggl entries")
+		return exitFailure
+	}
+	return writeReport(app, prepared, reportSettings, logger)
+}
+
+func runReconcile(app *app, args []string) int {
+	logger := app.logger
+	flagSet := app.newFlagSet("reconcile", "reconcile [flags]")
+	flags := registerCommonFlags(flagSet, app.now())
+
+	logger.Info().Msg("Parsing CLI flags")
+	if code, ok := parseFlags(flagSet, args); !ok {
+		return code
+	}
+
+	prepared, err := mapFlags(app, flags, logger)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error while mapping Toggl entries")
+		return exitFailure
+	}
+	storedEntries, err := prepared.sloneekClient.GetTimeEntries(prepared.since, prepared.until)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error while looking up stored entries")
+		return exitFailure
+	}
+
+	logger.Info().Msg("Comparing Toggl and Sloneek time entries")
+	result := reconcile.Compare(prepared.sloneekEntries, storedEntries, prepared.sloneekActivities, prepared.sloneekCategories, prepared.location)
+	err = reconcile.Render(result, app.stdout)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error while rendering reconciliation")
+		return exitFailure
+	}
+
+	if result.HasDifferences() {
+		return exitProblemsFound
+	}
+
+	return exitOk
+}
+
+func runList(app *app, args []string) int {
+	logger := app.logger
+	flagSet := app.newFlagSet("list", "list <activities|categories|projects> [flags]")
+	bearerToken := flagSet.String("bearer", "", "Bearer token obtained after login to Sloneek app")
+
+	if len(args) == 0 {
+		flagSet.Usage()
+		return exitUsage
+	}
+
+	what := args[0]
+	if what == "-h" || what == "--help" {
+		flagSet.Usage()
+		return exitOk
+	}
+
+	if code, ok := parseFlags(flagSet, args[1:]); !ok {
+		return code
+	}
+
+	rows := [][2]string{}
+	switch what {
+	case "activities", "categories":
+		if app.resolveBearer(bearerToken) == "" {
+			logger.Error().Msg("Sloneek JWT not found")
+			return exitUsage
+		}
+
+		sloneekClient := app.sloneekClient(app.defaultAccount(*bearerToken), logger)
+		if what == "activities" {
+			activities, err := sloneekClient.GetActivities()
+			if err != nil {
+				return exitFailure
+			}
+			for _, activity := range activities {
+				rows = append(rows, [2]string{activity.Id, activity.Name})
+			}
+		} else {
+			categories, err := sloneekClient.GetCategories()
+			if err != nil {
+				return exitFailure
+			}
+			for _, category := range categories {
+				rows = append(rows, [2]string{category.Id, category.Name})
+			}
+		}
+	case "projects":
+		projects, err := app.togglClient(app.defaultAccount(*bearerToken), logger).GetProjects()
+		if err != nil {
+			return exitFailure
+		}
+		for _, project := range projects {
+			rows = append(rows, [2]string{fmt.Sprint(project.Id), project.Name})
+		}
+	default:
+		fmt.Fprintf(app.stderr, "Unknown list target %q\n", what)
+		flagSet.Usage()
+		return exitUsage
+	}
+
+	writer := tabwriter.NewWriter(app.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "ID\tName\t")
+	for _, row := range rows {
+		fmt.Fprintf(writer, "%s\t%s\t\n", row[0], row[1])
+	}
+
+	if err := writer.Flush(); err != nil {
+		return exitFailure
+	}
+
+	return exitOk
+}
+
+func runDoctor(app *app, args []string) int {
+	logger := app.logger
+	flagSet := app.newFlagSet("doctor", "doctor [flags]")
+	flags := registerCommonFlags(flagSet, app.now())
+
+	if code, ok := parseFlags(flagSet, args); !ok {
+		return code
+	}
+
+	problems := 0
+	check := func(name string, err error) {
+		if err != nil {
+			problems++
+			fmt.Fprintf(app.stdout, "[FAIL] %s: %v\n", name, err)
+			return
+		}
+
+		fmt.Fprintf(app.stdout, "[ OK ] %s\n", name)
+	}
+
+	_, err := utils.LoadLocation(*flags.timezone)
+	check(fmt.Sprintf("timezone %s", *flags.timezone), err)
+
+	_, _, err = flags.rangeIn(time.UTC)
+	check("date range", err)
+
+	app.resolveBearer(flags.bearerToken)
+	if app.defaultAccount(*flags.bearerToken).togglApiKey == "" {
+		check("Toggl API key", errors.New("TOGGL_API_KEY is not set and not found in credentials store"))
+	} else {
+		check("Toggl API key", nil)
+		check("Toggl connection", app.togglClient(app.defaultAccount(*flags.bearerToken), logger).CheckConnection())
+	}
+
+	if *flags.bearerToken == "" {
+		check("Sloneek bearer token", errors.New("-bearer flag is not set and not found in credentials store"))
+	} else {
+		check("Sloneek bearer token", nil)
+		check("Sloneek connection", app.sloneekClient(app.defaultAccount(*flags.bearerToken), logger).CheckConnection())
+	}
+
+	if problems > 0 {
+		return exitProblemsFound
+	}
+
+	return exitOk
+}
@@ -0,0 +1,173 @@
+package contract
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"timetrack-sync/src/holidays"
+	"timetrack-sync/src/report"
+	"timetrack-sync/src/sloneek"
+	"timetrack-sync/src/utils"
+)
+
+// Day compares hours of a single day, Balance is the running balance including the day.
+type Day struct {
+	Date       string  `json:"date"`
+	Expected   float64 `json:"expected"`
+	Actual     float64 `json:"actual"`
+	Difference float64 `json:"difference"`
+	Balance    float64 `json:"balance"`
+	Note       string  `json:"note,omitempty"`
+}
+
+type Balance struct {
+	OpeningBalance float64 `json:"opening_balance"`
+	Expected       float64 `json:"expected"`
+	Actual         float64 `json:"actual"`
+	// Difference of the range, positive is overtime, negative a deficit
+	Difference float64 `json:"difference"`
+	Balance    float64 `json:"balance"`
+	Days       []Day   `json:"days"`
+}
+
+// Compare sums up entry hours per local day of the range and compares them with the contract.
+// Days not over by now expect nothing yet, only days with expected or actual hours are listed.
+func Compare(
+	entries []sloneek.TimeEntry,
+	contract *Contract,
+	holidays holidays.Lookup,
+	openingBalance float64,
+	since time.Time,
+	until time.Time,
+	now time.Time,
+	location *time.Location,
+) *Balance {
+	actualHours := make(map[string]float64)
+	for _, entry := range entries {
+		actualHours[utils.StartOfDay(entry.Since, location).Format(time.DateOnly)] += entry.GetHours()
+	}
+
+	balance := &Balance{OpeningBalance: openingBalance, Balance: openingBalance, Days: []Day{}}
+	for day := utils.StartOfDay(since, location); day.Before(until); day = day.AddDate(0, 0, 1) {
+		date := day.Format(time.DateOnly)
+		expected, note := contract.Expected(day, holidays)
+		if day.AddDate(0, 0, 1).After(now) {
+			expected = 0
+		}
+
+		actual := actualHours[date]
+		if expected == 0 && actual == 0 {
+			continue
+		}
+
+		balance.Expected += expected
+		balance.Actual += actual
+		balance.Balance += actual - expected
+		balance.Days = append(balance.Days, Day{
+			Date:       date,
+			Expected:   expected,
+			Actual:     actual,
+			Difference: actual - expected,
+			Balance:    balance.Balance,
+			Note:       note,
+		})
+	}
+
+	balance.Difference = balance.Actual - balance.Expected
+	return balance
+}
+
+func formatHours(hours float64) string {
+	return fmt.Sprintf("%.2f", hours)
+}
+
+// formatDifference signs overtime explicitly, so it is not mistaken for hours worked.
+func formatDifference(hours float64) string {
+	if hours > 0.005 {
+		return "+" + formatHours(hours)
+	}
+	if hours > -0.005 {
+		return formatHours(0)
+	}
+
+	return formatHours(hours)
+}
+
+// Render writes the balance in any of the report formats.
+func Render(balance *Balance, format report.Format, writer io.Writer) error {
+	if balance == nil {
+		return errors.New("Balance may not be nil")
+	}
+
+	switch format {
+	case report.FormatText:
+		return renderText(balance, writer)
+	case report.FormatCsv:
+		return renderCsv(balance, writer)
+	case report.FormatJson:
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(balance)
+	case report.FormatMarkdown:
+		return renderMarkdown(balance, writer)
+	}
+
+	return fmt.Errorf("Unknown report format %q", format)
+}
+
+func renderText(balance *Balance, writer io.Writer) error {
+	tabWriter := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tabWriter, "Day\tExpected\tActual\tDifference\tBalance\tNote\t")
+	if balance.OpeningBalance != 0 {
+		fmt.Fprintf(tabWriter, "Opening\t\t\t\t%s\t\t\n", formatDifference(balance.OpeningBalance))
+	}
+	for _, day := range balance.Days {
+		fmt.Fprintf(tabWriter, "%s\t%s\t%s\t%s\t%s\t%s\t\n",
+			day.Date, formatHours(day.Expected), formatHours(day.Actual), formatDifference(day.Difference), formatDifference(day.Balance), day.Note)
+	}
+	fmt.Fprintf(tabWriter, "Total\t%s\t%s\t%s\t%s\t\t\n",
+		formatHours(balance.Expected), formatHours(balance.Actual), formatDifference(balance.Difference), formatDifference(balance.Balance))
+
+	return tabWriter.Flush()
+}
+
+func renderCsv(balance *Balance, writer io.Writer) error {
+	records := [][]string{{"day", "expected", "actual", "difference", "balance", "note"}}
+	if balance.OpeningBalance != 0 {
+		records = append(records, []string{"opening", "", "", "", formatHours(balance.OpeningBalance), ""})
+	}
+	for _, day := range balance.Days {
+		records = append(records, []string{
+			day.Date, formatHours(day.Expected), formatHours(day.Actual), formatHours(day.Difference), formatHours(day.Balance), day.Note,
+		})
+	}
+	records = append(records, []string{
+		"total", formatHours(balance.Expected), formatHours(balance.Actual), formatHours(balance.Difference), formatHours(balance.Balance), "",
+	})
+
+	return csv.NewWriter(writer).WriteAll(records)
+}
+
+func renderMarkdown(balance *Balance, writer io.Writer) error {
+	builder := strings.Builder{}
+	builder.WriteString("| Day | Expected | Actual | Difference | Balance | Note |\n")
+	builder.WriteString("| --- | ---: | ---: | ---: | ---: | --- |\n")
+	if balance.OpeningBalance != 0 {
+		fmt.Fprintf(&builder, "| Opening | | | | %s | |\n", formatDifference(balance.OpeningBalance))
+	}
+	for _, day := range balance.Days {
+		fmt.Fprintf(&builder, "| %s | %s | %s | %s | %s | %s |\n",
+			day.Date, formatHours(day.Expected), formatHours(day.Actual), formatDifference(day.Difference), formatDifference(day.Balance),
+			strings.ReplaceAll(day.Note, "|", "\\|"))
+	}
+	fmt.Fprintf(&builder, "| **Total** | %s | %s | %s | %s | |\n",
+		formatHours(balance.Expected), formatHours(balance.Actual), formatDifference(balance.Difference), formatDifference(balance.Balance))
+
+	_, err := io.WriteString(writer, builder.String())
+	return err
+}
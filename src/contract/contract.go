@@ -0,0 +1,132 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+	"timetrack-sync/src/holidays"
+)
+
+// Contract says how many hours are expected on a day. A Schedule of weekday names (e.g. "monday")
+// describes part-time contracts, without it HoursPerDay are expected Monday to Friday and
+// WeeklyHours spread evenly over them when HoursPerDay is not set. WeeklyHours given next to
+// either of them must match their week.
+type Contract struct {
+	HoursPerDay float64            `json:"hours_per_day"`
+	WeeklyHours float64            `json:"weekly_hours"`
+	Schedule    map[string]float64 `json:"schedule,omitempty"`
+}
+
+// DefaultContract is a full-time contract of 8 hours a day, 40 a week.
+func DefaultContract() Contract {
+	return Contract{HoursPerDay: 8, WeeklyHours: 40}
+}
+
+// Load reads a contract file, a file without any hours keeps the default full-time contract.
+func Load(path string) (*Contract, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	contract := Contract{}
+	err = json.Unmarshal(content, &contract)
+	if err != nil {
+		return nil, fmt.Errorf("invalid contract %s: %w", path, err)
+	}
+
+	err = contract.validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid contract %s: %w", path, err)
+	}
+
+	if contract.HoursPerDay == 0 && contract.WeeklyHours == 0 && len(contract.Schedule) == 0 {
+		contract = DefaultContract()
+	}
+
+	return &contract, nil
+}
+
+func (contract *Contract) validate() error {
+	if contract.HoursPerDay < 0 || contract.HoursPerDay > 24 || contract.WeeklyHours < 0 {
+		return fmt.Errorf("hours out of range")
+	}
+
+	scheduled := 0.0
+	for name, hours := range contract.Schedule {
+		if _, ok := weekdays[strings.ToLower(name)]; !ok {
+			return fmt.Errorf("unknown weekday %q", name)
+		}
+		if hours < 0 || hours > 24 {
+			return fmt.Errorf("hours of %s out of range", name)
+		}
+		scheduled += hours
+	}
+
+	if contract.WeeklyHours == 0 {
+		return nil
+	}
+	if len(contract.Schedule) > 0 && math.Abs(scheduled-contract.WeeklyHours) > 0.01 {
+		return fmt.Errorf("weekly_hours %.2f differ from %.2f hours of the schedule", contract.WeeklyHours, scheduled)
+	}
+	if len(contract.Schedule) == 0 && contract.HoursPerDay > 0 && math.Abs(contract.HoursPerDay*5-contract.WeeklyHours) > 0.01 {
+		return fmt.Errorf("weekly_hours %.2f differ from 5 days of %.2f hours_per_day", contract.WeeklyHours, contract.HoursPerDay)
+	}
+
+	return nil
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// Expected returns hours expected on the day, holidays may be nil. Nothing is expected on
+// holidays, their name is returned as the reason.
+func (contract *Contract) Expected(day time.Time, holidays holidays.Lookup) (float64, string) {
+	if holidays != nil {
+		if name, ok := holidays.Holiday(day); ok {
+			return 0, name
+		}
+	}
+
+	if len(contract.Schedule) > 0 {
+		for name, hours := range contract.Schedule {
+			if weekdays[strings.ToLower(name)] == day.Weekday() {
+				return hours, ""
+			}
+		}
+
+		return 0, ""
+	}
+
+	if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+		return 0, ""
+	}
+	if contract.HoursPerDay > 0 {
+		return contract.HoursPerDay, ""
+	}
+
+	return contract.WeeklyHours / 5, ""
+}
+
+// PreviousWorkday returns the last day before the day that expects any hours, it looks at most
+// a month back.
+func (contract *Contract) PreviousWorkday(day time.Time, holidays holidays.Lookup) (time.Time, bool) {
+	for i := 1; i <= 31; i++ {
+		previous := day.AddDate(0, 0, -i)
+		if expected, _ := contract.Expected(previous, holidays); expected > 0 {
+			return previous, true
+		}
+	}
+
+	return time.Time{}, false
+}
@@ -0,0 +1,185 @@
+package contract
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"timetrack-sync/src/report"
+	"timetrack-sync/src/sloneek"
+	testutils "timetrack-sync/src/testUtils"
+)
+
+func TestExpectedHours(t *testing.T) {
+	fullTime := DefaultContract()
+	weekly := Contract{WeeklyHours: 30}
+	partTime := Contract{Schedule: map[string]float64{"Monday": 8, "wednesday": 4}}
+	holidays := testutils.FixedHolidays{"2024-05-01": "Svátek práce"}
+
+	testCases := []struct {
+		Name     string
+		Contract Contract
+		Day      string
+		Expected float64
+	}{
+		{Name: "full-time workday", Contract: fullTime, Day: "2024-04-29", Expected: 8},
+		{Name: "full-time weekend", Contract: fullTime, Day: "2024-04-28", Expected: 0},
+		{Name: "holiday", Contract: fullTime, Day: "2024-05-01", Expected: 0},
+		{Name: "weekly hours", Contract: weekly, Day: "2024-04-30", Expected: 6},
+		{Name: "part-time monday", Contract: partTime, Day: "2024-04-29", Expected: 8},
+		{Name: "part-time tuesday", Contract: partTime, Day: "2024-04-30", Expected: 0},
+		{Name: "part-time holiday", Contract: partTime, Day: "2024-05-01", Expected: 0},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			expected, _ := testCase.Contract.Expected(testutils.DateFromString(testCase.Day), holidays)
+			if expected != testCase.Expected {
+				t.Errorf("Expected %.2f hours, got %.2f", testCase.Expected, expected)
+			}
+		})
+	}
+}
+
+func TestPreviousWorkday(t *testing.T) {
+	fullTime := DefaultContract()
+	partTime := Contract{Schedule: map[string]float64{"tuesday": 4}}
+	holidays := testutils.FixedHolidays{"2024-05-08": "Den vítězství"}
+
+	testCases := []struct {
+		Name     string
+		Contract Contract
+		Day      string
+		Expected string
+	}{
+		{Name: "tuesday", Contract: fullTime, Day: "2024-05-07", Expected: "2024-05-06"},
+		{Name: "monday skips the weekend", Contract: fullTime, Day: "2024-05-06", Expected: "2024-05-03"},
+		{Name: "after a holiday", Contract: fullTime, Day: "2024-05-09", Expected: "2024-05-07"},
+		{Name: "part-time", Contract: partTime, Day: "2024-05-06", Expected: "2024-04-30"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			previous, ok := testCase.Contract.PreviousWorkday(testutils.DateFromString(testCase.Day), holidays)
+			if !ok || previous.Format(time.DateOnly) != testCase.Expected {
+				t.Errorf("Expected %s, got %v", testCase.Expected, previous)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsUnknownWeekday(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "contract.json")
+	os.WriteFile(path, []byte(`{"schedule": {"funday": 8}}`), 0o600)
+
+	_, err := Load(path)
+	if err == nil || !strings.Contains(err.Error(), "funday") {
+		t.Errorf("Expected unknown weekday error, got %v", err)
+	}
+}
+
+func TestLoadRejectsDisagreeingHours(t *testing.T) {
+	testCases := map[string]string{
+		"hours per day": `{"hours_per_day": 8, "weekly_hours": 30}`,
+		"schedule":      `{"weekly_hours": 30, "schedule": {"monday": 8}}`,
+	}
+
+	for name, content := range testCases {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "contract.json")
+			os.WriteFile(path, []byte(content), 0o600)
+
+			_, err := Load(path)
+			if err == nil || !strings.Contains(err.Error(), "weekly_hours") {
+				t.Errorf("Expected disagreeing hours rejected, got %v", err)
+			}
+		})
+	}
+}
+
+func buildTestBalance(t *testing.T) *Balance {
+	t.Helper()
+	entries := []sloneek.TimeEntry{
+		// Monday, one hour short
+		{Since: testutils.DateTimeFromString("2024-04-29 08:00:00", t), Until: testutils.DateTimeFromString("2024-04-29 15:00:00", t)},
+		// Wednesday is a holiday
+		{Since: testutils.DateTimeFromString("2024-05-01 09:00:00", t), Until: testutils.DateTimeFromString("2024-05-01 11:00:00", t)},
+		// Thursday, half an hour of overtime
+		{Since: testutils.DateTimeFromString("2024-05-02 08:00:00", t), Until: testutils.DateTimeFromString("2024-05-02 12:00:00", t)},
+		{Since: testutils.DateTimeFromString("2024-05-02 12:30:00", t), Until: testutils.DateTimeFromString("2024-05-02 17:00:00", t)},
+	}
+	contract := DefaultContract()
+	holidays := testutils.FixedHolidays{"2024-05-01": "Svátek práce"}
+	now := testutils.DateTimeFromString("2024-05-03 00:00:00", t)
+
+	return Compare(entries, &contract, holidays, 1.5, testutils.DateFromString("2024-04-29"), testutils.DateFromString("2024-05-06"), now, time.UTC)
+}
+
+func TestCompareKeepsRunningBalance(t *testing.T) {
+	balance := buildTestBalance(t)
+
+	expected := []Day{
+		{Date: "2024-04-29", Expected: 8, Actual: 7, Difference: -1, Balance: 0.5},
+		{Date: "2024-04-30", Expected: 8, Actual: 0, Difference: -8, Balance: -7.5},
+		{Date: "2024-05-01", Expected: 0, Actual: 2, Difference: 2, Balance: -5.5, Note: "Svátek práce"},
+		{Date: "2024-05-02", Expected: 8, Actual: 8.5, Difference: 0.5, Balance: -5},
+	}
+	if len(balance.Days) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, balance.Days)
+	}
+	for i := range expected {
+		if balance.Days[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], balance.Days[i])
+		}
+	}
+
+	// Friday is after now and the weekend expects nothing
+	if balance.Expected != 24 || balance.Actual != 17.5 || balance.Difference != -6.5 || balance.Balance != -5 {
+		t.Errorf("Unexpected totals %+v", balance)
+	}
+}
+
+func TestCompareExpectsTodayOnceItIsOver(t *testing.T) {
+	entries := []sloneek.TimeEntry{
+		{Since: testutils.DateTimeFromString("2024-05-02 08:00:00", t), Until: testutils.DateTimeFromString("2024-05-02 12:00:00", t)},
+	}
+	contract := DefaultContract()
+	now := testutils.DateTimeFromString("2024-05-02 12:00:00", t)
+
+	balance := Compare(entries, &contract, nil, 0, testutils.DateFromString("2024-05-02"), testutils.DateFromString("2024-05-03"), now, time.UTC)
+
+	if balance.Expected != 0 || balance.Actual != 4 || balance.Balance != 4 {
+		t.Errorf("Expected nothing of the running day, got %+v", balance)
+	}
+}
+
+func TestRenderBalance(t *testing.T) {
+	balance := buildTestBalance(t)
+
+	testCases := []struct {
+		Format   report.Format
+		Contains []string
+	}{
+		{Format: report.FormatText, Contains: []string{"Opening", "2024-04-29  8.00      7.00    -1.00       +0.50", "Svátek práce", "Total"}},
+		{Format: report.FormatCsv, Contains: []string{"day,expected,actual,difference,balance,note", "2024-05-02,8.00,8.50,0.50,-5.00,", "total,24.00,17.50,-6.50,-5.00,"}},
+		{Format: report.FormatJson, Contains: []string{`"opening_balance": 1.5`, `"note": "Svátek práce"`}},
+		{Format: report.FormatMarkdown, Contains: []string{"| 2024-05-02 | 8.00 | 8.50 | +0.50 | -5.00 |  |", "| **Total** |"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(string(testCase.Format), func(t *testing.T) {
+			buffer := bytes.Buffer{}
+			err := Render(balance, testCase.Format, &buffer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, expected := range testCase.Contains {
+				if !strings.Contains(buffer.String(), expected) {
+					t.Errorf("Expected %q in:\n%s", expected, buffer.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/zalando/go-keyring"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	TogglApiKey   = "toggl_api_key"
+	SloneekBearer = "sloneek_bearer"
+
+	keyringService = "timetrack-sync"
+)
+
+var (
+	ErrNotFound          = errors.New("Credential not found")
+	ErrWrongPassphrase   = errors.New("Wrong passphrase or corrupted credentials file")
+	ErrUnknownBackend    = errors.New("Unknown credentials backend")
+	ErrMissingPassphrase = errors.New("Passphrase for the credentials file is empty")
+)
+
+// Store keeps secrets outside of .env files and command line arguments.
+type Store interface {
+	Get(name string) (string, error)
+	Set(name string, value string) error
+	Delete(name string) error
+}
+
+// KeyringStore uses the OS keyring, which is the Secret Service (GNOME Keyring, KWallet) on Linux.
+type KeyringStore struct{}
+
+func (store *KeyringStore) Get(name string) (string, error) {
+	value, err := keyring.Get(keyringService, name)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", ErrNotFound
+	}
+
+	return value, err
+}
+
+func (store *KeyringStore) Set(name string, value string) error {
+	return keyring.Set(keyringService, name, value)
+}
+
+func (store *KeyringStore) Delete(name string) error {
+	err := keyring.Delete(keyringService, name)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return ErrNotFound
+	}
+
+	return err
+}
+
+// scrypt parameters recommended for interactive logins
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	keyLength    = 32
+	saltLength   = 16
+	fileVersion  = 1
+	filePermMode = 0o600
+)
+
+type encryptedFile struct {
+	Version    int    `json:"version"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// EncryptedFileStore keeps all secrets in a single AES-256-GCM encrypted file. The key is derived
+// from a passphrase with scrypt and a random salt, which is regenerated on every write.
+type EncryptedFileStore struct {
+	path       string
+	passphrase []byte
+}
+
+func CreateEncryptedFileStore(path string, passphrase string) (*EncryptedFileStore, error) {
+	if passphrase == "" {
+		return nil, ErrMissingPassphrase
+	}
+
+	return &EncryptedFileStore{path: path, passphrase: []byte(passphrase)}, nil
+}
+
+func (store *EncryptedFileStore) gcm(salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key(store.passphrase, salt, scryptN, scryptR, scryptP, keyLength)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func (store *EncryptedFileStore) read() (map[string]string, error) {
+	payload, err := os.ReadFile(store.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var file encryptedFile
+	err = json.Unmarshal(payload, &file)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid credentials file: %w", err)
+	}
+	if file.Version != fileVersion {
+		return nil, fmt.Errorf("Unsupported credentials file version %d", file.Version)
+	}
+
+	gcm, err := store.gcm(file.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := gcm.Open(nil, file.Nonce, file.Ciphertext, nil)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+
+	secrets := map[string]string{}
+	err = json.Unmarshal(plaintext, &secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	return secrets, nil
+}
+
+func (store *EncryptedFileStore) write(secrets map[string]string) error {
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+
+	salt := make([]byte, saltLength)
+	_, err = rand.Read(salt)
+	if err != nil {
+		return err
+	}
+
+	gcm, err := store.gcm(salt)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(encryptedFile{
+		Version:    fileVersion,
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plaintext, nil),
+	})
+	if err != nil {
+		return err
+	}
+
+	// write next to the target and rename, so an interrupted write never loses all secrets
+	temporaryPath := store.path + ".tmp"
+	err = os.WriteFile(temporaryPath, payload, filePermMode)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(temporaryPath, store.path)
+}
+
+func (store *EncryptedFileStore) Get(name string) (string, error) {
+	secrets, err := store.read()
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := secrets[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+
+	return value, nil
+}
+
+func (store *EncryptedFileStore) Set(name string, value string) error {
+	secrets, err := store.read()
+	if err != nil {
+		return err
+	}
+
+	secrets[name] = value
+	return store.write(secrets)
+}
+
+func (store *EncryptedFileStore) Delete(name string) error {
+	secrets, err := store.read()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := secrets[name]; !ok {
+		return ErrNotFound
+	}
+
+	delete(secrets, name)
+	return store.write(secrets)
+}
+
+// Lookup returns the credential or an empty string when the store does not have it.
+// A nil store means no backend is configured.
+func Lookup(store Store, name string) (string, error) {
+	if store == nil {
+		return "", nil
+	}
+
+	value, err := store.Get(name)
+	if errors.Is(err, ErrNotFound) {
+		return "", nil
+	}
+
+	return value, err
+}
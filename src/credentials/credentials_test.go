@@ -0,0 +1,89 @@
+package credentials
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncryptedFileStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.enc")
+	store, err := CreateEncryptedFileStore(path, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.Get(TogglApiKey)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for empty store, got %v", err)
+	}
+
+	err = store.Set(TogglApiKey, "secret-api-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.Set(SloneekBearer, "secret-bearer")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a new store instance must read what the previous one wrote
+	reopened, _ := CreateEncryptedFileStore(path, "correct horse")
+	value, err := reopened.Get(TogglApiKey)
+	if err != nil || value != "secret-api-key" {
+		t.Errorf("Expected stored API key, got %q (%v)", value, err)
+	}
+
+	err = reopened.Delete(TogglApiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = reopened.Get(TogglApiKey)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+	if value, _ := reopened.Get(SloneekBearer); value != "secret-bearer" {
+		t.Errorf("Delete removed other credentials")
+	}
+}
+
+func TestEncryptedFileStoreDoesNotLeakSecrets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.enc")
+	store, _ := CreateEncryptedFileStore(path, "correct horse")
+	err := store.Set(TogglApiKey, "secret-api-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(payload, []byte("secret-api-key")) || bytes.Contains(payload, []byte(TogglApiKey)) {
+		t.Errorf("Credentials file contains plaintext: %s", payload)
+	}
+
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected file readable only by owner, got %v", info.Mode().Perm())
+	}
+}
+
+func TestEncryptedFileStoreRejectsWrongPassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.enc")
+	store, _ := CreateEncryptedFileStore(path, "correct horse")
+	store.Set(TogglApiKey, "secret-api-key")
+
+	wrong, _ := CreateEncryptedFileStore(path, "battery staple")
+	_, err := wrong.Get(TogglApiKey)
+	if !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Expected ErrWrongPassphrase, got %v", err)
+	}
+
+	_, err = CreateEncryptedFileStore(path, "")
+	if !errors.Is(err, ErrMissingPassphrase) {
+		t.Errorf("Expected ErrMissingPassphrase, got %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"timetrack-sync/src/credentials"
+
+	"golang.org/x/term"
+)
+
+const (
+	credentialsBackendEnv = "TIMETRACK_CREDENTIALS_BACKEND"
+	credentialsFileEnv    = "TIMETRACK_CREDENTIALS_FILE"
+	passphraseEnv         = "TIMETRACK_PASSPHRASE"
+)
+
+var credentialNames = []string{credentials.TogglApiKey, credentials.SloneekBearer}
+
+// readSecret reads a line without echo from a terminal, or a plain line from piped stdin.
+func (app *app) readSecret(prompt string) (string, error) {
+	if file, ok := app.stdin.(*os.File); ok && term.IsTerminal(int(file.Fd())) {
+		fmt.Fprint(app.stderr, prompt)
+		value, err := term.ReadPassword(int(file.Fd()))
+		fmt.Fprintln(app.stderr)
+		return string(value), err
+	}
+
+	line, err := app.input().ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// openCredentialStore returns nil when no backend is configured.
+func (app *app) openCredentialStore(backend string) (credentials.Store, error) {
+	switch backend {
+	case "":
+		return nil, nil
+	case "keyring":
+		return &credentials.KeyringStore{}, nil
+	case "file":
+		path := app.getenv(credentialsFileEnv)
+		if path == "" {
+			path = "credentials.enc"
+		}
+
+		passphrase := app.getenv(passphraseEnv)
+		if passphrase == "" {
+			var err error
+			passphrase, err = app.readSecret("Credentials passphrase: ")
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return credentials.CreateEncryptedFileStore(path, passphrase)
+	}
+
+	return nil, fmt.Errorf("%w %q", credentials.ErrUnknownBackend, backend)
+}
+
+// storedCredential looks the credential up in the store configured by the environment.
+// Problems are logged and treated as a missing credential.
+func (app *app) storedCredential(name string) string {
+	app.storeOnce.Do(func() {
+		store, err := app.openCredentialStore(app.getenv(credentialsBackendEnv))
+		if err != nil {
+			app.logger.Error().Err(err).Msg("Error while opening credentials store")
+		}
+
+		app.store = store
+	})
+
+	value, err := credentials.Lookup(app.store, name)
+	app.addSecrets(value)
+	if err != nil {
+		app.logger.Error().Err(err).Str("credential", name).Msg("Error while reading credential")
+	}
+
+	return value
+}
+
+// resolveBearer fills an empty bearer flag from the credentials store and returns the result.
+func (app *app) resolveBearer(bearerToken *string) string {
+	if *bearerToken == "" {
+		*bearerToken = app.storedCredential(credentials.SloneekBearer)
+	}
+	app.addSecrets(*bearerToken)
+
+	return *bearerToken
+}
+
+func runCredentials(app *app, args []string) int {
+	logger := app.logger
+	flagSet := app.newFlagSet("credentials", fmt.Sprintf("credentials <set|get|delete> <%s> [flags]", strings.Join(credentialNames, "|")))
+	backend := flagSet.String("backend", app.getenv(credentialsBackendEnv), "Credentials backend: keyring or file. Defaults to "+credentialsBackendEnv+".")
+
+	if len(args) < 2 || slices.Contains(args[:2], "-h") || slices.Contains(args[:2], "--help") {
+		flagSet.Usage()
+		if len(args) >= 1 && (args[0] == "-h" || args[0] == "--help") {
+			return exitOk
+		}
+		return exitUsage
+	}
+
+	action, name := args[0], args[1]
+	if code, ok := parseFlags(flagSet, args[2:]); !ok {
+		return code
+	}
+
+	if !slices.Contains(credentialNames, name) {
+		fmt.Fprintf(app.stderr, "Unknown credential %q\n", name)
+		return exitUsage
+	}
+	if *backend == "" {
+		fmt.Fprintf(app.stderr, "No credentials backend, use -backend or set %s\n", credentialsBackendEnv)
+		return exitUsage
+	}
+
+	store, err := app.openCredentialStore(*backend)
+	if errors.Is(err, credentials.ErrUnknownBackend) {
+		fmt.Fprintln(app.stderr, err)
+		return exitUsage
+	}
+	if err != nil {
+		logger.Error().Err(err).Msg("Error while opening credentials store")
+		return exitFailure
+	}
+
+	switch action {
+	case "set":
+		// the value is never taken from arguments, so it does not end up in shell history or ps
+		value, err := app.readSecret(fmt.Sprintf("Value of %s: ", name))
+		if err != nil || value == "" {
+			logger.Error().Err(err).Msg("No value entered")
+			return exitUsage
+		}
+
+		err = store.Set(name, value)
+		if err != nil {
+			logger.Error().Err(err).Msg("Error while storing credential")
+			return exitFailure
+		}
+	case "get":
+		value, err := store.Get(name)
+		if errors.Is(err, credentials.ErrNotFound) {
+			fmt.Fprintf(app.stderr, "Credential %s is not stored\n", name)
+			return exitProblemsFound
+		}
+		if err != nil {
+			logger.Error().Err(err).Msg("Error while reading credential")
+			return exitFailure
+		}
+
+		fmt.Fprintln(app.stdout, value)
+	case "delete":
+		err := store.Delete(name)
+		if errors.Is(err, credentials.ErrNotFound) {
+			fmt.Fprintf(app.stderr, "Credential %s is not stored\n", name)
+			return exitProblemsFound
+		}
+		if err != nil {
+			logger.Error().Err(err).Msg("Error while deleting credential")
+			return exitFailure
+		}
+	default:
+		fmt.Fprintf(app.stderr, "Unknown action %q\n", action)
+		flagSet.Usage()
+		return exitUsage
+	}
+
+	return exitOk
+}
@@ -0,0 +1,134 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+var ErrLocked = errors.New("Another instance holds the lock")
+
+type Lock struct {
+	file *os.File
+}
+
+// WaitLock retries AcquireLock until the lock is free or the context is done.
+func WaitLock(ctx context.Context, path string, retryEvery time.Duration) (*Lock, error) {
+	for {
+		lock, err := AcquireLock(path)
+		if !errors.Is(err, ErrLocked) {
+			return lock, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(retryEvery):
+		}
+	}
+}
+
+// State is persisted between runs so syncs can catch up after the daemon was down.
+type State struct {
+	LastSuccess time.Time `json:"last_success"`
+	// LastReminder is when missing tracking was last checked, it is checked once a day
+	LastReminder time.Time `json:"last_reminder,omitempty"`
+}
+
+func ReadState(path string) (*State, error) {
+	payload, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &State{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var state State
+	err = json.Unmarshal(payload, &state)
+	if err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+func WriteState(state *State, path string) error {
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, payload, 0o600)
+}
+
+// SyncWindow returns the range to sync at the given time: yesterday and today, extended back
+// to the day of the last successful sync (at most maxCatchUp days) when it is older.
+func SyncWindow(now time.Time, lastSuccess time.Time, maxCatchUp int, location *time.Location) (time.Time, time.Time) {
+	local := now.In(location)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+	since := today.AddDate(0, 0, -1)
+	if !lastSuccess.IsZero() {
+		last := lastSuccess.In(location)
+		lastDay := time.Date(last.Year(), last.Month(), last.Day(), 0, 0, 0, 0, location)
+		earliest := today.AddDate(0, 0, -maxCatchUp)
+		if lastDay.Before(earliest) {
+			lastDay = earliest
+		}
+		if lastDay.Before(since) {
+			since = lastDay
+		}
+	}
+
+	return since, today.AddDate(0, 0, 1)
+}
+
+// ReminderDue tells whether the daily reminder check should run: it is hour:minute local time
+// or later and the check did not run since then.
+func ReminderDue(now time.Time, lastReminder time.Time, hour int, minute int, location *time.Location) bool {
+	local := now.In(location)
+	dueAt := time.Date(local.Year(), local.Month(), local.Day(), hour, minute, 0, 0, location)
+
+	return !now.Before(dueAt) && lastReminder.Before(dueAt)
+}
+
+type Job func(ctx context.Context) error
+
+// Run executes the job immediately and then by the schedule until the context is canceled.
+// A running job is never interrupted by the scheduler, cancellation is passed to it via ctx.
+func Run(ctx context.Context, schedule Schedule, job Job, now func() time.Time, logger *zerolog.Logger) {
+	next := now()
+	for {
+		wait := next.Sub(now())
+		if wait < 0 {
+			wait = 0
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			logger.Info().Msg("Daemon stopped")
+			return
+		case <-timer.C:
+		}
+
+		logger.Info().Msg("Running scheduled sync")
+		err := job(ctx)
+		if err != nil {
+			logger.Error().Err(err).Msg("Scheduled sync failed")
+		}
+
+		next = schedule.Next(now())
+		if next.IsZero() {
+			logger.Error().Msg("Schedule has no further runs")
+			return
+		}
+
+		logger.Info().Time("next_run", next).Msg("Waiting for next run")
+	}
+}
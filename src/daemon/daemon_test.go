@@ -0,0 +1,194 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+	testutils "timetrack-sync/src/testUtils"
+
+	"github.com/rs/zerolog"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+	testCases := []struct {
+		Expression string
+		After      string
+		Expected   string
+	}{
+		{Expression: "*/30 * * * *", After: "2024-09-02 10:05:00", Expected: "2024-09-02 10:30:00"},
+		{Expression: "*/30 * * * *", After: "2024-09-02 10:30:00", Expected: "2024-09-02 11:00:00"},
+		{Expression: "0 8-18/2 * * *", After: "2024-09-02 18:00:00", Expected: "2024-09-03 08:00:00"},
+		// 2024-09-06 is Friday, next weekday run is on Monday
+		{Expression: "15 7 * * 1-5", After: "2024-09-06 08:00:00", Expected: "2024-09-09 07:15:00"},
+		{Expression: "0 0 1 * *", After: "2024-09-02 10:00:00", Expected: "2024-10-01 00:00:00"},
+		// day of month or Sunday when both are restricted
+		{Expression: "0 6 15 * 0", After: "2024-09-02 10:00:00", Expected: "2024-09-08 06:00:00"},
+		{Expression: "0 6 29 2 *", After: "2024-03-01 00:00:00", Expected: "2028-02-29 06:00:00"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Expression+" after "+testCase.After, func(t *testing.T) {
+			schedule, err := ParseCron(testCase.Expression, time.UTC)
+			if err != nil {
+				t.Fatalf("Parsing failed: %v", err)
+			}
+
+			result := schedule.Next(testutils.DateTimeFromString(testCase.After, t))
+			expected := testutils.DateTimeFromString(testCase.Expected, t)
+			if !result.Equal(expected) {
+				t.Errorf("Expected %v, got %v", expected, result)
+			}
+		})
+	}
+}
+
+func TestParseScheduleRejectsInvalidInput(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Interval time.Duration
+		Cron     string
+	}{
+		{Name: "both", Interval: time.Minute, Cron: "* * * * *"},
+		{Name: "none"},
+		{Name: "four fields", Cron: "* * * *"},
+		{Name: "out of range", Cron: "60 * * * *"},
+		{Name: "zero step", Cron: "*/0 * * * *"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			_, err := ParseSchedule(testCase.Interval, testCase.Cron, time.UTC)
+			if err == nil {
+				t.Errorf("Expected to fail but did not fail")
+			}
+		})
+	}
+}
+
+func TestSyncWindow(t *testing.T) {
+	now := testutils.DateTimeFromString("2024-09-10 12:00:00", t)
+
+	testCases := []struct {
+		Name          string
+		LastSuccess   time.Time
+		ExpectedSince string
+	}{
+		{Name: "first run", ExpectedSince: "2024-09-09 00:00:00"},
+		{Name: "recent success", LastSuccess: testutils.DateTimeFromString("2024-09-10 11:30:00", t), ExpectedSince: "2024-09-09 00:00:00"},
+		{Name: "catch up", LastSuccess: testutils.DateTimeFromString("2024-09-06 17:00:00", t), ExpectedSince: "2024-09-06 00:00:00"},
+		{Name: "catch up limit", LastSuccess: testutils.DateTimeFromString("2024-08-01 17:00:00", t), ExpectedSince: "2024-09-03 00:00:00"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			since, until := SyncWindow(now, testCase.LastSuccess, 7, time.UTC)
+			if !since.Equal(testutils.DateTimeFromString(testCase.ExpectedSince, t)) {
+				t.Errorf("Expected since %s, got %v", testCase.ExpectedSince, since)
+			}
+			if !until.Equal(testutils.DateTimeFromString("2024-09-11 00:00:00", t)) {
+				t.Errorf("Expected until to be tomorrow, got %v", until)
+			}
+		})
+	}
+}
+
+func TestReminderDue(t *testing.T) {
+	testCases := []struct {
+		Name         string
+		Now          string
+		LastReminder time.Time
+		Expected     bool
+	}{
+		{Name: "before time", Now: "2024-09-10 07:59:00", Expected: false},
+		{Name: "first run", Now: "2024-09-10 08:00:00", Expected: true},
+		{Name: "yesterday", Now: "2024-09-10 12:00:00", LastReminder: testutils.DateTimeFromString("2024-09-09 08:30:00", t), Expected: true},
+		{Name: "already today", Now: "2024-09-10 12:00:00", LastReminder: testutils.DateTimeFromString("2024-09-10 08:30:00", t), Expected: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			due := ReminderDue(testutils.DateTimeFromString(testCase.Now, t), testCase.LastReminder, 8, 0, time.UTC)
+			if due != testCase.Expected {
+				t.Errorf("Expected %v, got %v", testCase.Expected, due)
+			}
+		})
+	}
+}
+
+func TestAcquireLockIsExclusive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "daemon.lock")
+
+	lock, err := AcquireLock(path)
+	if err != nil {
+		t.Fatalf("First lock failed: %v", err)
+	}
+
+	_, err = AcquireLock(path)
+	if !errors.Is(err, ErrLocked) {
+		t.Errorf("Expected ErrLocked, got %v", err)
+	}
+
+	err = lock.Release()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err = AcquireLock(path)
+	if err != nil {
+		t.Fatalf("Lock after release failed: %v", err)
+	}
+	lock.Release()
+}
+
+func TestWaitLockWaitsForRelease(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "daemon.lock")
+	lock, err := AcquireLock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = WaitLock(ctx, path, 10*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the wait to time out, got %v", err)
+	}
+
+	held := lock
+	time.AfterFunc(20*time.Millisecond, func() { held.Release() })
+	waited, err := WaitLock(context.Background(), path, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Expected the lock after release, got %v", err)
+	}
+	waited.Release()
+}
+
+func TestRunStopsWhenContextIsCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	runs := 0
+	job := func(ctx context.Context) error {
+		runs++
+		if runs == 3 {
+			cancel()
+		}
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		Run(ctx, &IntervalSchedule{Interval: time.Millisecond}, job, time.Now, &zerolog.Logger{})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Daemon did not stop after cancellation")
+	}
+
+	if runs != 3 {
+		t.Errorf("Expected 3 runs, got %d", runs)
+	}
+}
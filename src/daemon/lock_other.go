@@ -0,0 +1,27 @@
+//go:build !unix
+
+package daemon
+
+import (
+	"errors"
+	"os"
+)
+
+// AcquireLock creates the lock file exclusively. Unlike the unix implementation a crashed
+// process leaves the file behind and it has to be removed manually.
+func AcquireLock(path string) (*Lock, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, os.ErrExist) {
+		return nil, ErrLocked
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Lock{file: file}, nil
+}
+
+func (lock *Lock) Release() error {
+	lock.file.Close()
+	return os.Remove(lock.file.Name())
+}
@@ -0,0 +1,35 @@
+//go:build unix
+
+package daemon
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// AcquireLock takes an exclusive, non-blocking lock of the file. The lock is released by the
+// kernel when the process dies, so a crashed daemon never leaves a stale lock behind.
+func AcquireLock(path string) (*Lock, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		file.Close()
+		return nil, ErrLocked
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &Lock{file: file}, nil
+}
+
+func (lock *Lock) Release() error {
+	syscall.Flock(int(lock.file.Fd()), syscall.LOCK_UN)
+	return lock.file.Close()
+}
@@ -0,0 +1,179 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Schedule interface {
+	// Next returns the first run time strictly after the given time.
+	Next(after time.Time) time.Time
+}
+
+type IntervalSchedule struct {
+	Interval time.Duration
+}
+
+func (schedule *IntervalSchedule) Next(after time.Time) time.Time {
+	return after.Add(schedule.Interval)
+}
+
+// CronSchedule supports the standard five field cron syntax (minute, hour, day of month,
+// month, day of week) with lists, ranges and steps. Times are evaluated in Location.
+type CronSchedule struct {
+	minutes     []bool
+	hours       []bool
+	daysOfMonth []bool
+	months      []bool
+	daysOfWeek  []bool
+	// cron matches either day field when both are restricted
+	anyDayOfMonth bool
+	anyDayOfWeek  bool
+	Location      *time.Location
+}
+
+func ParseCron(expression string, location *time.Location) (*CronSchedule, error) {
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("Cron expression %q must have 5 fields", expression)
+	}
+
+	schedule := &CronSchedule{Location: location}
+	var err error
+	parsers := []struct {
+		target *[]bool
+		min    int
+		max    int
+	}{
+		{&schedule.minutes, 0, 59},
+		{&schedule.hours, 0, 23},
+		{&schedule.daysOfMonth, 1, 31},
+		{&schedule.months, 1, 12},
+		{&schedule.daysOfWeek, 0, 7},
+	}
+
+	for i, parser := range parsers {
+		*parser.target, err = parseCronField(fields[i], parser.min, parser.max)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid cron field %q: %w", fields[i], err)
+		}
+	}
+
+	// both 0 and 7 mean Sunday
+	schedule.daysOfWeek[0] = schedule.daysOfWeek[0] || schedule.daysOfWeek[7]
+	schedule.anyDayOfMonth = fields[2] == "*"
+	schedule.anyDayOfWeek = fields[4] == "*"
+	return schedule, nil
+}
+
+func parseCronField(field string, min int, max int) ([]bool, error) {
+	allowed := make([]bool, max+1)
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if rangePart, stepPart, found := strings.Cut(part, "/"); found {
+			value, err := strconv.Atoi(stepPart)
+			if err != nil || value <= 0 {
+				return nil, errors.New("Invalid step")
+			}
+
+			step = value
+			part = rangePart
+		}
+
+		start, end := min, max
+		if part != "*" {
+			startPart, endPart, isRange := strings.Cut(part, "-")
+			value, err := strconv.Atoi(startPart)
+			if err != nil {
+				return nil, errors.New("Invalid value")
+			}
+
+			start, end = value, value
+			if isRange {
+				end, err = strconv.Atoi(endPart)
+				if err != nil {
+					return nil, errors.New("Invalid range")
+				}
+			} else if step > 1 {
+				// "5/15" means every 15 starting at 5
+				end = max
+			}
+		}
+
+		if start < min || end > max || start > end {
+			return nil, fmt.Errorf("Value out of range %d-%d", min, max)
+		}
+
+		for value := start; value <= end; value += step {
+			allowed[value] = true
+		}
+	}
+
+	return allowed, nil
+}
+
+func (schedule *CronSchedule) matchesDay(value time.Time) bool {
+	dayOfMonth := schedule.daysOfMonth[value.Day()]
+	dayOfWeek := schedule.daysOfWeek[int(value.Weekday())]
+	switch {
+	case schedule.anyDayOfMonth && schedule.anyDayOfWeek:
+		return true
+	case schedule.anyDayOfMonth:
+		return dayOfWeek
+	case schedule.anyDayOfWeek:
+		return dayOfMonth
+	}
+
+	return dayOfMonth || dayOfWeek
+}
+
+func (schedule *CronSchedule) Next(after time.Time) time.Time {
+	location := schedule.Location
+	if location == nil {
+		location = time.Local
+	}
+
+	candidate := after.In(location).Truncate(time.Minute).Add(time.Minute)
+	// a matching minute always exists within a few years, the limit only guards against bugs
+	limit := candidate.AddDate(5, 0, 0)
+	for candidate.Before(limit) {
+		if !schedule.months[int(candidate.Month())] {
+			candidate = time.Date(candidate.Year(), candidate.Month()+1, 1, 0, 0, 0, 0, location)
+			continue
+		}
+		if !schedule.matchesDay(candidate) {
+			candidate = time.Date(candidate.Year(), candidate.Month(), candidate.Day()+1, 0, 0, 0, 0, location)
+			continue
+		}
+		if !schedule.hours[candidate.Hour()] {
+			candidate = time.Date(candidate.Year(), candidate.Month(), candidate.Day(), candidate.Hour()+1, 0, 0, 0, location)
+			continue
+		}
+		if !schedule.minutes[candidate.Minute()] {
+			candidate = candidate.Add(time.Minute)
+			continue
+		}
+
+		return candidate
+	}
+
+	return time.Time{}
+}
+
+// ParseSchedule creates a schedule from exactly one of an interval or a cron expression.
+func ParseSchedule(interval time.Duration, cronExpression string, location *time.Location) (Schedule, error) {
+	if cronExpression != "" && interval > 0 {
+		return nil, errors.New("Interval and cron expression are mutually exclusive")
+	}
+	if cronExpression != "" {
+		return ParseCron(cronExpression, location)
+	}
+	if interval <= 0 {
+		return nil, errors.New("Interval must be positive")
+	}
+
+	return &IntervalSchedule{Interval: interval}, nil
+}
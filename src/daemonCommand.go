@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os/signal"
+	"syscall"
+	"time"
+	"timetrack-sync/src/daemon"
+	"timetrack-sync/src/notify"
+	"timetrack-sync/src/plan"
+	"timetrack-sync/src/utils"
+
+	"github.com/rs/zerolog"
+)
+
+const defaultLockPath = "timetrack-sync.lock"
+
+func runDaemon(app *app, args []string) int {
+	logger := app.logger
+	flagSet := app.newFlagSet("daemon", "daemon [flags]")
+	bearerToken := flagSet.String("bearer", "", "Bearer token obtained after login to Sloneek app")
+	timezone := flagSet.String("timezone", "Local", "IANA timezone (e.g. Europe/Prague) used for day boundaries, rounding and cron expressions.")
+	interval := flagSet.Duration("interval", 0, "Sync every given duration, e.g. 30m.")
+	cronExpression := flagSet.String("cron", "", "Sync by a five field cron expression instead of an interval, e.g. \"*/30 8-18 * * 1-5\".")
+	lockPath := flagSet.String("lock", defaultLockPath, "Lock file preventing concurrent syncs, shared with the webhook and serve commands.")
+	statePath := flagSet.String("state", "timetrack-sync.state.json", "File storing the time of the last successful sync.")
+	maxCatchUp := flagSet.Int("max-catch-up-days", 7, "How many days back to sync after the daemon was not running.")
+	allowDelete := flagSet.Bool("delete", false, "Delete synced Sloneek entries whose Toggl entry is gone. Off by default as nobody reviews the daemon's changes.")
+	remindAt := flagSet.String("remind-at", "08:00", "Local time after which the previous workday is checked for missing Toggl time once a day, requires TIMETRACK_NOTIFIERS.")
+
+	if code, ok := parseFlags(flagSet, args); !ok {
+		return code
+	}
+
+	if app.resolveBearer(bearerToken) == "" {
+		logger.Error().Msg("Sloneek JWT not found")
+		return exitUsage
+	}
+
+	location, err := utils.LoadLocation(*timezone)
+	if err != nil {
+		logger.Error().Err(err).Str("timezone", *timezone).Msg("Unknown timezone")
+		return exitUsage
+	}
+
+	schedule, err := daemon.ParseSchedule(*interval, *cronExpression, location)
+	if err != nil {
+		logger.Error().Err(err).Msg("Invalid schedule")
+		return exitUsage
+	}
+
+	remindTime, err := time.Parse("15:04", *remindAt)
+	if err != nil {
+		logger.Error().Err(err).Str("remind_at", *remindAt).Msg("Invalid reminder time, expected HH:MM")
+		return exitUsage
+	}
+
+	notifier, err := app.notifier()
+	if err != nil {
+		logger.Error().Err(err).Msg("Invalid notifier")
+		return exitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(app.ctx, syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	job := func(ctx context.Context) error {
+		state, err := daemon.ReadState(*statePath)
+		if err != nil {
+			return err
+		}
+
+		startedAt := app.now()
+		account := app.defaultAccount(*bearerToken)
+		since, until := daemon.SyncWindow(startedAt, state.LastSuccess, *maxCatchUp, location)
+		logger.Info().Time("since", since).Time("until", until).Msg("Syncing window")
+
+		syncErr := lockedSync(ctx, *lockPath, func() error {
+			return syncRange(app, account, location, since, until, *allowDelete, logger)
+		})
+		if syncErr == nil {
+			state.LastSuccess = startedAt
+		}
+
+		// reminders do not depend on the sync, Toggl is checked directly
+		if notifier != nil && daemon.ReminderDue(startedAt, state.LastReminder, remindTime.Hour(), remindTime.Minute(), location) {
+			err = remindMissingTime(ctx, app, account, notifier, location, startedAt, logger)
+			switch {
+			case errors.Is(err, notify.ErrPartiallyDelivered):
+				// repeating it would send it again through the notifiers that worked
+				logger.Warn().Err(err).Msg("Reminder of missing tracking was not delivered everywhere")
+				state.LastReminder = startedAt
+			case err != nil:
+				logger.Error().Err(err).Msg("Reminder of missing tracking failed")
+			default:
+				state.LastReminder = startedAt
+			}
+		}
+
+		err = daemon.WriteState(state, *statePath)
+		if syncErr != nil {
+			return syncErr
+		}
+
+		return err
+	}
+
+	logger.Info().Msg("Daemon started")
+	daemon.Run(ctx, schedule, job, app.now, logger)
+	return exitOk
+}
+
+// lockedSync runs the sync holding the lock file shared by the daemon, webhook and serve
+// commands, waiting while another of them writes to Sloneek.
+func lockedSync(ctx context.Context, lockPath string, sync func() error) error {
+	lock, err := daemon.WaitLock(ctx, lockPath, time.Second)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	return sync()
+}
+
+// syncRange plans and applies the changes of the range right away.
+func syncRange(app *app, account *account, location *time.Location, since time.Time, until time.Time, allowDelete bool, logger *zerolog.Logger) error {
+	prepared, err := prepareSyncRange(app, account, location, since, until, logger)
+	if err != nil {
+		return err
+	}
+
+	return applyRange(prepared, allowDelete, logger)
+}
+
+// applyRange diffs prepared entries against stored ones and applies the result. Diffing keeps
+// repeated syncs of the same days idempotent. Deletes are dropped unless allowed.
+func applyRange(prepared *syncContext, allowDelete bool, logger *zerolog.Logger) error {
+	storedEntries, err := prepared.sloneekClient.GetTimeEntries(prepared.since, prepared.until)
+	if err != nil {
+		return err
+	}
+
+	syncPlan := plan.Build(prepared.sloneekEntries, storedEntries, prepared.sloneekActivities, prepared.sloneekCategories, prepared.since, prepared.until)
+	if !allowDelete {
+		if skipped := syncPlan.SkipDeletes(); skipped > 0 {
+			logger.Warn().Int("skipped", skipped).Msg("Synced entries without a Toggl counterpart were kept, pass -delete to remove them")
+		}
+	}
+
+	return plan.Apply(syncPlan, storedEntries, prepared.sloneekClient, logger)
+}
@@ -0,0 +1,26 @@
+// Package fakeapi provides in-memory fakes of the Toggl and Sloneek APIs used by the clients
+// and a local SMTP stand-in, for tests and for local development without real accounts.
+package fakeapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+func writeJson(writer http.ResponseWriter, status int, payload any) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	json.NewEncoder(writer).Encode(payload)
+}
+
+// parseTimeQuery reads an RFC3339 query parameter, missing values give the zero time.
+func parseTimeQuery(request *http.Request, name string) (time.Time, bool) {
+	value := request.URL.Query().Get(name)
+	if value == "" {
+		return time.Time{}, true
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	return parsed, err == nil
+}
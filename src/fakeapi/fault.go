@@ -0,0 +1,89 @@
+package fakeapi
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fault makes matching requests fail or respond slowly instead of being served.
+type Fault struct {
+	// PathPrefix selects the affected requests, empty matches all of them
+	PathPrefix string
+	// Status is sent instead of the real response, zero serves the request normally after Delay
+	Status int
+	Delay  time.Duration
+	// Times limits how many requests are affected, zero means all of them
+	Times int
+}
+
+// faults holds injected faults, the first matching one is applied to a request.
+type faults struct {
+	mutex  sync.Mutex
+	faults []*Fault
+}
+
+// Inject adds a fault applied to following requests.
+func (faults *faults) Inject(fault Fault) {
+	faults.mutex.Lock()
+	defer faults.mutex.Unlock()
+
+	faults.faults = append(faults.faults, &fault)
+}
+
+// ClearFaults removes all injected faults.
+func (faults *faults) ClearFaults() {
+	faults.mutex.Lock()
+	defer faults.mutex.Unlock()
+
+	faults.faults = nil
+}
+
+func (faults *faults) take(path string) *Fault {
+	faults.mutex.Lock()
+	defer faults.mutex.Unlock()
+
+	for i, fault := range faults.faults {
+		if !strings.HasPrefix(path, fault.PathPrefix) {
+			continue
+		}
+
+		applied := *fault
+		if fault.Times > 0 {
+			fault.Times--
+			if fault.Times == 0 {
+				faults.faults = append(faults.faults[:i], faults.faults[i+1:]...)
+			}
+		}
+		return &applied
+	}
+
+	return nil
+}
+
+// apply serves the fault of the request, it returns false when the request should not be handled further.
+func (faults *faults) apply(writer http.ResponseWriter, request *http.Request) bool {
+	fault := faults.take(request.URL.Path)
+	if fault == nil {
+		return true
+	}
+
+	if fault.Delay > 0 {
+		select {
+		case <-time.After(fault.Delay):
+		case <-request.Context().Done():
+			return false
+		}
+	}
+
+	if fault.Status == 0 {
+		return true
+	}
+
+	if fault.Status == http.StatusTooManyRequests {
+		writer.Header().Set("Retry-After", "1")
+	}
+	writeJson(writer, fault.Status, map[string]any{"message": http.StatusText(fault.Status), "status_code": fault.Status})
+	return false
+}
@@ -0,0 +1,240 @@
+package fakeapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const scheduledEventsPath = "/v2/module-planning/scheduled-events"
+
+type SloneekCategory struct {
+	Uuid string `json:"uuid"`
+	Name string `json:"name"`
+}
+
+// SloneekActivity is a user planning event, the activity a scheduled event is logged to.
+type SloneekActivity struct {
+	Uuid              string `json:"uuid"`
+	PlanningEventUuid string `json:"planning_event_uuid"`
+	Name              string `json:"name"`
+}
+
+type SloneekEvent struct {
+	Uuid                   string    `json:"uuid"`
+	UserUuid               string    `json:"user_uuid,omitempty"`
+	ActivityUuid           string    `json:"activity_uuid"`
+	CategoryUuids          []string  `json:"category_uuids"`
+	StartedAt              time.Time `json:"started_at"`
+	EndedAt                time.Time `json:"ended_at"`
+	Note                   string    `json:"note"`
+	IsAutomaticallyApprove bool      `json:"is_automatically_approve"`
+	// ApprovalStatus defaults to "approved" for automatically approved events and "pending" otherwise
+	ApprovalStatus string `json:"approval_status,omitempty"`
+}
+
+// SloneekState is the data served by the fake Sloneek API.
+type SloneekState struct {
+	Bearer     string            `json:"bearer"`
+	Categories []SloneekCategory `json:"categories"`
+	Activities []SloneekActivity `json:"activities"`
+	Events     []SloneekEvent    `json:"events"`
+}
+
+// Sloneek serves the scheduled events module authenticated by the bearer token. Created events
+// get UUIDs "event-1", "event-2" and so on.
+type Sloneek struct {
+	faults
+	mutex  sync.Mutex
+	state  SloneekState
+	nextId int
+}
+
+func CreateSloneek(state SloneekState) *Sloneek {
+	return &Sloneek{state: state, nextId: len(state.Events) + 1}
+}
+
+// Events returns a copy of the stored scheduled events.
+func (sloneek *Sloneek) Events() []SloneekEvent {
+	sloneek.mutex.Lock()
+	defer sloneek.mutex.Unlock()
+
+	return slices.Clone(sloneek.state.Events)
+}
+
+// AddEvent stores an event as if it was entered in the Sloneek app.
+func (sloneek *Sloneek) AddEvent(event SloneekEvent) {
+	sloneek.mutex.Lock()
+	defer sloneek.mutex.Unlock()
+
+	event.Uuid = fmt.Sprintf("event-%d", sloneek.nextId)
+	sloneek.nextId++
+	sloneek.state.Events = append(sloneek.state.Events, event)
+}
+
+func (sloneek *Sloneek) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if !sloneek.faults.apply(writer, request) {
+		return
+	}
+
+	sloneek.mutex.Lock()
+	defer sloneek.mutex.Unlock()
+
+	if request.Header.Get("Authorization") != "Bearer "+sloneek.state.Bearer {
+		writeSloneek(writer, http.StatusUnauthorized, nil)
+		return
+	}
+
+	path := request.URL.Path
+	switch {
+	case path == scheduledEventsPath+"/options/categories" && request.Method == http.MethodGet:
+		writeSloneek(writer, http.StatusOK, sloneek.state.Categories)
+	case path == scheduledEventsPath+"/options/user-planning-events" && request.Method == http.MethodGet:
+		sloneek.serveActivities(writer)
+	case path == scheduledEventsPath && request.Method == http.MethodGet:
+		sloneek.serveEvents(writer, request)
+	case path == scheduledEventsPath && request.Method == http.MethodPost:
+		sloneek.saveEvent(writer, request, "")
+	case strings.HasPrefix(path, scheduledEventsPath+"/") && request.Method == http.MethodPut:
+		sloneek.saveEvent(writer, request, strings.TrimPrefix(path, scheduledEventsPath+"/"))
+	case strings.HasPrefix(path, scheduledEventsPath+"/") && request.Method == http.MethodDelete:
+		sloneek.deleteEvent(writer, strings.TrimPrefix(path, scheduledEventsPath+"/"))
+	default:
+		writeSloneek(writer, http.StatusNotFound, nil)
+	}
+}
+
+// writeSloneek wraps the payload in the Sloneek response envelope.
+func writeSloneek(writer http.ResponseWriter, status int, data any) {
+	writeJson(writer, status, map[string]any{"message": http.StatusText(status), "status_code": status, "data": data})
+}
+
+func (sloneek *Sloneek) serveActivities(writer http.ResponseWriter) {
+	data := make([]map[string]any, len(sloneek.state.Activities))
+	for i, activity := range sloneek.state.Activities {
+		data[i] = map[string]any{
+			"uuid":           activity.Uuid,
+			"planning_event": map[string]any{"uuid": activity.PlanningEventUuid, "name": activity.Name},
+		}
+	}
+
+	writeSloneek(writer, http.StatusOK, data)
+}
+
+func (sloneek *Sloneek) serveEvents(writer http.ResponseWriter, request *http.Request) {
+	startedAt, startedOk := parseTimeQuery(request, "started_at")
+	endedAt, endedOk := parseTimeQuery(request, "ended_at")
+	if !startedOk || !endedOk {
+		writeSloneek(writer, http.StatusUnprocessableEntity, nil)
+		return
+	}
+	userUuid := request.URL.Query().Get("user_uuid")
+
+	data := []map[string]any{}
+	for _, event := range sloneek.state.Events {
+		if userUuid != "" && event.UserUuid != userUuid {
+			continue
+		}
+		if (!startedAt.IsZero() && event.EndedAt.Before(startedAt)) || (!endedAt.IsZero() && event.StartedAt.After(endedAt)) {
+			continue
+		}
+
+		categories := []SloneekCategory{}
+		for _, categoryUuid := range event.CategoryUuids {
+			index := slices.IndexFunc(sloneek.state.Categories, func(category SloneekCategory) bool { return category.Uuid == categoryUuid })
+			if index != -1 {
+				categories = append(categories, sloneek.state.Categories[index])
+			}
+		}
+
+		approvalStatus := event.ApprovalStatus
+		if approvalStatus == "" && event.IsAutomaticallyApprove {
+			approvalStatus = "approved"
+		} else if approvalStatus == "" {
+			approvalStatus = "pending"
+		}
+
+		data = append(data, map[string]any{
+			"uuid":                event.Uuid,
+			"user_planning_event": map[string]any{"uuid": event.ActivityUuid},
+			"planning_categories": categories,
+			"started_at":          event.StartedAt,
+			"ended_at":            event.EndedAt,
+			"note":                event.Note,
+			"approval_status":     approvalStatus,
+		})
+	}
+
+	writeSloneek(writer, http.StatusOK, data)
+}
+
+type eventPayload struct {
+	UserUuid               string    `json:"user_uuid"`
+	UserPlanningEventUuid  string    `json:"user_planning_event_uuid"`
+	PlanningCategories     []string  `json:"planning_categories"`
+	StartedAt              time.Time `json:"started_at"`
+	EndedAt                time.Time `json:"ended_at"`
+	Note                   string    `json:"note"`
+	IsAutomaticallyApprove bool      `json:"is_automatically_approve"`
+}
+
+// saveEvent creates an event, or overwrites the event with given UUID when not empty.
+func (sloneek *Sloneek) saveEvent(writer http.ResponseWriter, request *http.Request, uuid string) {
+	var payload eventPayload
+	err := json.NewDecoder(request.Body).Decode(&payload)
+	if err != nil {
+		writeSloneek(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !slices.ContainsFunc(sloneek.state.Activities, func(activity SloneekActivity) bool { return activity.Uuid == payload.UserPlanningEventUuid }) {
+		writeSloneek(writer, http.StatusUnprocessableEntity, "Unknown user planning event")
+		return
+	}
+	if !payload.EndedAt.After(payload.StartedAt) {
+		writeSloneek(writer, http.StatusUnprocessableEntity, "Event must end after it starts")
+		return
+	}
+
+	event := SloneekEvent{
+		Uuid:                   uuid,
+		UserUuid:               payload.UserUuid,
+		ActivityUuid:           payload.UserPlanningEventUuid,
+		CategoryUuids:          payload.PlanningCategories,
+		StartedAt:              payload.StartedAt,
+		EndedAt:                payload.EndedAt,
+		Note:                   payload.Note,
+		IsAutomaticallyApprove: payload.IsAutomaticallyApprove,
+	}
+
+	if uuid == "" {
+		event.Uuid = fmt.Sprintf("event-%d", sloneek.nextId)
+		sloneek.nextId++
+		sloneek.state.Events = append(sloneek.state.Events, event)
+		writeSloneek(writer, http.StatusOK, map[string]any{"uuid": event.Uuid})
+		return
+	}
+
+	index := slices.IndexFunc(sloneek.state.Events, func(existing SloneekEvent) bool { return existing.Uuid == uuid })
+	if index == -1 {
+		writeSloneek(writer, http.StatusNotFound, nil)
+		return
+	}
+
+	sloneek.state.Events[index] = event
+	writeSloneek(writer, http.StatusOK, map[string]any{"uuid": uuid})
+}
+
+func (sloneek *Sloneek) deleteEvent(writer http.ResponseWriter, uuid string) {
+	index := slices.IndexFunc(sloneek.state.Events, func(event SloneekEvent) bool { return event.Uuid == uuid })
+	if index == -1 {
+		writeSloneek(writer, http.StatusNotFound, nil)
+		return
+	}
+
+	sloneek.state.Events = slices.Delete(sloneek.state.Events, index, index+1)
+	writeSloneek(writer, http.StatusOK, nil)
+}
@@ -0,0 +1,124 @@
+package fakeapi
+
+import (
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+)
+
+// SmtpMessage is a mail accepted by the fake SMTP server, Data holds headers and body.
+type SmtpMessage struct {
+	From string
+	To   []string
+	Data string
+}
+
+// Smtp is a local SMTP stand-in accepting any mail without authentication or TLS.
+type Smtp struct {
+	listener  net.Listener
+	mutex     sync.Mutex
+	messages  []SmtpMessage
+	delivered chan struct{}
+}
+
+// StartSmtp listens on a random local port until Close.
+func StartSmtp() (*Smtp, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	server := &Smtp{listener: listener, messages: []SmtpMessage{}, delivered: make(chan struct{}, 100)}
+	go server.serve()
+	return server, nil
+}
+
+// Addr is the host:port clients connect to.
+func (server *Smtp) Addr() string {
+	return server.listener.Addr().String()
+}
+
+func (server *Smtp) Close() error {
+	return server.listener.Close()
+}
+
+// Messages returns a copy of all accepted mails.
+func (server *Smtp) Messages() []SmtpMessage {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	return append([]SmtpMessage{}, server.messages...)
+}
+
+// Delivered receives a value for every accepted mail.
+func (server *Smtp) Delivered() <-chan struct{} {
+	return server.delivered
+}
+
+func (server *Smtp) serve() {
+	for {
+		connection, err := server.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go server.handle(connection)
+	}
+}
+
+func (server *Smtp) handle(connection net.Conn) {
+	defer connection.Close()
+	text := textproto.NewConn(connection)
+	text.PrintfLine("220 fakeapi SMTP ready")
+
+	message := SmtpMessage{}
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+
+		command, argument, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(command) {
+		case "EHLO", "HELO":
+			text.PrintfLine("250 fakeapi")
+		case "MAIL":
+			message = SmtpMessage{From: trimAddress(argument)}
+			text.PrintfLine("250 OK")
+		case "RCPT":
+			message.To = append(message.To, trimAddress(argument))
+			text.PrintfLine("250 OK")
+		case "DATA":
+			text.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			lines, err := text.ReadDotLines()
+			if err != nil {
+				return
+			}
+
+			message.Data = strings.Join(lines, "\n")
+			server.mutex.Lock()
+			server.messages = append(server.messages, message)
+			server.mutex.Unlock()
+			text.PrintfLine("250 OK")
+			select {
+			case server.delivered <- struct{}{}:
+			default:
+			}
+		case "RSET", "NOOP":
+			text.PrintfLine("250 OK")
+		case "QUIT":
+			text.PrintfLine("221 Bye")
+			return
+		default:
+			text.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+// trimAddress turns "FROM:<a@b>" into "a@b".
+func trimAddress(argument string) string {
+	_, address, _ := strings.Cut(argument, ":")
+	address, _, _ = strings.Cut(strings.TrimSpace(address), " ")
+	return strings.Trim(address, "<>")
+}
@@ -0,0 +1,125 @@
+package fakeapi
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TogglPrefix is the path the Toggl API is served under, clients use server URL + TogglPrefix.
+const TogglPrefix = "/api/v9"
+
+type TogglProject struct {
+	Id   int32  `json:"id"`
+	Name string `json:"name"`
+}
+
+type TogglTimeEntry struct {
+	Id          int64     `json:"id"`
+	ProjectId   *int32    `json:"project_id,omitempty"`
+	Start       time.Time `json:"start"`
+	Stop        time.Time `json:"stop"`
+	Duration    int64     `json:"duration"`
+	Description string    `json:"description"`
+}
+
+// TogglState is the data served by the fake Toggl API.
+type TogglState struct {
+	ApiKey      string           `json:"api_key"`
+	WorkspaceId int32            `json:"workspace_id"`
+	Projects    []TogglProject   `json:"projects"`
+	TimeEntries []TogglTimeEntry `json:"time_entries"`
+}
+
+// Toggl serves /me, /me/time_entries and /workspaces/{id}/projects authenticated by the API key.
+type Toggl struct {
+	faults
+	mutex sync.Mutex
+	state TogglState
+}
+
+// CreateToggl serves the state, durations missing in entries are computed from start and stop.
+func CreateToggl(state TogglState) *Toggl {
+	for i, entry := range state.TimeEntries {
+		if entry.Duration == 0 {
+			state.TimeEntries[i].Duration = togglDuration(entry)
+		}
+	}
+
+	return &Toggl{state: state}
+}
+
+// AddTimeEntry adds an entry, its duration is computed from start and stop.
+func (toggl *Toggl) AddTimeEntry(entry TogglTimeEntry) {
+	toggl.mutex.Lock()
+	defer toggl.mutex.Unlock()
+
+	entry.Duration = togglDuration(entry)
+	toggl.state.TimeEntries = append(toggl.state.TimeEntries, entry)
+}
+
+// togglDuration is the entry length in seconds. Like Toggl, an entry without a stop is running
+// and has the negative start timestamp as duration.
+func togglDuration(entry TogglTimeEntry) int64 {
+	if entry.Stop.IsZero() {
+		return -entry.Start.Unix()
+	}
+
+	return int64(entry.Stop.Sub(entry.Start).Seconds())
+}
+
+func (toggl *Toggl) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if !toggl.faults.apply(writer, request) {
+		return
+	}
+
+	toggl.mutex.Lock()
+	defer toggl.mutex.Unlock()
+
+	user, password, ok := request.BasicAuth()
+	if !ok || user != toggl.state.ApiKey || password != "api_token" {
+		writeJson(writer, http.StatusForbidden, "Incorrect username and/or password")
+		return
+	}
+	if request.Method != http.MethodGet {
+		writeJson(writer, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	path := strings.TrimPrefix(request.URL.Path, TogglPrefix)
+	switch {
+	case path == "/me":
+		writeJson(writer, http.StatusOK, map[string]any{"id": 1, "default_workspace_id": toggl.state.WorkspaceId})
+	case path == "/me/time_entries":
+		toggl.serveTimeEntries(writer, request)
+	case path == fmt.Sprintf("/workspaces/%d/projects", toggl.state.WorkspaceId):
+		writeJson(writer, http.StatusOK, toggl.state.Projects)
+	case strings.HasPrefix(path, "/workspaces/"):
+		writeJson(writer, http.StatusForbidden, "User does not have access to this resource.")
+	default:
+		http.NotFound(writer, request)
+	}
+}
+
+func (toggl *Toggl) serveTimeEntries(writer http.ResponseWriter, request *http.Request) {
+	start, startOk := parseTimeQuery(request, "start_date")
+	end, endOk := parseTimeQuery(request, "end_date")
+	if !startOk || !endOk {
+		writeJson(writer, http.StatusBadRequest, "Invalid start_date or end_date")
+		return
+	}
+
+	entries := []TogglTimeEntry{}
+	for _, entry := range toggl.state.TimeEntries {
+		if (start.IsZero() || !entry.Start.Before(start)) && (end.IsZero() || entry.Start.Before(end)) {
+			entries = append(entries, entry)
+		}
+	}
+	// Toggl returns the latest entries first
+	slices.SortFunc(entries, func(a, b TogglTimeEntry) int { return b.Start.Compare(a.Start) })
+
+	writeJson(writer, http.StatusOK, entries)
+}
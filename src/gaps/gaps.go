@@ -0,0 +1,132 @@
+package gaps
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+	"time"
+	"timetrack-sync/src/contract"
+	"timetrack-sync/src/holidays"
+	toggltrack "timetrack-sync/src/togglTrack"
+	"timetrack-sync/src/utils"
+)
+
+type Kind string
+
+const (
+	// KindMissing is a working day without any tracked time
+	KindMissing Kind = "missing"
+	// KindShort is a working day with less time than required
+	KindShort Kind = "short"
+	// KindGap is a pause between two entries of a day longer than allowed
+	KindGap Kind = "gap"
+)
+
+// Settings configure what counts as a gap. Zero MinHours require the hours expected by the
+// contract, zero MaxGap disables intra-day gaps.
+type Settings struct {
+	MinHours float64
+	MaxGap   time.Duration
+}
+
+type Gap struct {
+	Day  time.Time
+	Kind Kind
+	// Since and Until bound an intra-day gap
+	Since time.Time
+	Until time.Time
+	// Hours tracked on the day and hours required
+	Hours    float64
+	Required float64
+}
+
+func (gap *Gap) Message() string {
+	switch gap.Kind {
+	case KindMissing:
+		return fmt.Sprintf("nothing tracked, %.2f h expected", gap.Required)
+	case KindShort:
+		return fmt.Sprintf("%.2f h tracked, %.2f h expected", gap.Hours, gap.Required)
+	}
+
+	location := gap.Day.Location()
+	return fmt.Sprintf("%s-%s untracked (%s)", gap.Since.In(location).Format("15:04"), gap.Until.In(location).Format("15:04"), gap.Until.Sub(gap.Since))
+}
+
+// Find lists working days of the range in the location with no or too little Toggl time and
+// gaps between entries of any day. Days the contract expects nothing on (weekends, holidays)
+// are never missing or short, neither is the current day before it is over. Running entries
+// are counted until now.
+func Find(
+	entries []toggltrack.TimeEntry,
+	workContract *contract.Contract,
+	holidays holidays.Lookup,
+	settings Settings,
+	since time.Time,
+	until time.Time,
+	now time.Time,
+	location *time.Location,
+) []Gap {
+	days := map[time.Time][]toggltrack.TimeEntry{}
+	for _, entry := range entries {
+		if entry.Stop.IsZero() {
+			entry.Stop = now
+		}
+		for _, part := range utils.SplitTimeEntriesAtMidnight([]toggltrack.TimeEntry{entry}, location) {
+			day := utils.StartOfDay(part.Start, location)
+			days[day] = append(days[day], part)
+		}
+	}
+
+	gaps := []Gap{}
+	for day := utils.StartOfDay(since, location); day.Before(until); day = day.AddDate(0, 0, 1) {
+		dayEntries := days[day]
+		slices.SortFunc(dayEntries, func(a, b toggltrack.TimeEntry) int { return a.Start.Compare(b.Start) })
+
+		hours := 0.0
+		for _, entry := range dayEntries {
+			hours += entry.Stop.Sub(entry.Start).Hours()
+		}
+
+		expected, _ := workContract.Expected(day, holidays)
+		required := expected
+		if settings.MinHours > 0 && expected > 0 {
+			required = settings.MinHours
+		}
+
+		dayOver := !day.AddDate(0, 0, 1).After(now)
+		switch {
+		case !dayOver || required == 0:
+		case len(dayEntries) == 0:
+			gaps = append(gaps, Gap{Day: day, Kind: KindMissing, Required: required})
+		case hours < required:
+			gaps = append(gaps, Gap{Day: day, Kind: KindShort, Hours: hours, Required: required})
+		}
+
+		if settings.MaxGap <= 0 || len(dayEntries) == 0 {
+			continue
+		}
+		blockEnd := dayEntries[0].Stop
+		for _, entry := range dayEntries[1:] {
+			if entry.Start.Sub(blockEnd) > settings.MaxGap {
+				gaps = append(gaps, Gap{Day: day, Kind: KindGap, Since: blockEnd, Until: entry.Start, Hours: hours, Required: required})
+			}
+			if entry.Stop.After(blockEnd) {
+				blockEnd = entry.Stop
+			}
+		}
+	}
+
+	return gaps
+}
+
+// Render prints gaps ordered by day.
+func Render(gaps []Gap, writer io.Writer) error {
+	tableWriter := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tableWriter, "Day\tKind\tGap\t")
+	for _, gap := range gaps {
+		fmt.Fprintf(tableWriter, "%s\t%s\t%s\t\n", gap.Day.Format("Mon 2006-01-02"), gap.Kind, gap.Message())
+	}
+
+	return tableWriter.Flush()
+}
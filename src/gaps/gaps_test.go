@@ -0,0 +1,80 @@
+package gaps
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"timetrack-sync/src/contract"
+	testutils "timetrack-sync/src/testUtils"
+	toggltrack "timetrack-sync/src/togglTrack"
+)
+
+func entry(since string, until string, t *testing.T) toggltrack.TimeEntry {
+	entry := toggltrack.TimeEntry{Start: testutils.DateTimeFromString(since, t)}
+	if until != "" {
+		entry.Stop = testutils.DateTimeFromString(until, t)
+	}
+	return entry
+}
+
+func findTestGaps(t *testing.T, settings Settings) []Gap {
+	t.Helper()
+	entries := []toggltrack.TimeEntry{
+		// Monday, full day with a long lunch
+		entry("2024-04-29 08:00:00", "2024-04-29 12:00:00", t),
+		entry("2024-04-29 14:00:00", "2024-04-29 18:00:00", t),
+		// Tuesday, only a morning, overlapping entries count twice like in a sync but make no gap
+		entry("2024-04-30 08:00:00", "2024-04-30 11:00:00", t),
+		entry("2024-04-30 10:00:00", "2024-04-30 12:00:00", t),
+		// Thursday is today, still running
+		entry("2024-05-02 08:00:00", "", t),
+	}
+	workContract := contract.DefaultContract()
+	holidays := testutils.FixedHolidays{"2024-05-01": "Svátek práce"}
+	now := testutils.DateTimeFromString("2024-05-02 10:00:00", t)
+
+	return Find(entries, &workContract, holidays, settings, testutils.DateFromString("2024-04-26"), testutils.DateFromString("2024-05-04"), now, time.UTC)
+}
+
+func TestFindReportsMissingShortAndIntraDayGaps(t *testing.T) {
+	gaps := findTestGaps(t, Settings{MaxGap: time.Hour})
+
+	expected := []struct {
+		Day     string
+		Kind    Kind
+		Message string
+	}{
+		{Day: "2024-04-26", Kind: KindMissing, Message: "nothing tracked, 8.00 h expected"},
+		{Day: "2024-04-29", Kind: KindGap, Message: "12:00-14:00 untracked (2h0m0s)"},
+		{Day: "2024-04-30", Kind: KindShort, Message: "5.00 h tracked, 8.00 h expected"},
+	}
+	if len(gaps) != len(expected) {
+		t.Fatalf("Expected %v, got %+v", expected, gaps)
+	}
+	for i := range expected {
+		if gaps[i].Day.Format(time.DateOnly) != expected[i].Day || gaps[i].Kind != expected[i].Kind || gaps[i].Message() != expected[i].Message {
+			t.Errorf("Expected %v, got %s %s %s", expected[i], gaps[i].Day.Format(time.DateOnly), gaps[i].Kind, gaps[i].Message())
+		}
+	}
+}
+
+func TestFindWithMinHoursAndWithoutIntraDayGaps(t *testing.T) {
+	gaps := findTestGaps(t, Settings{MinHours: 3})
+
+	if len(gaps) != 1 || gaps[0].Kind != KindMissing {
+		t.Errorf("Expected only the missing Friday, got %+v", gaps)
+	}
+}
+
+func TestRenderGaps(t *testing.T) {
+	buffer := bytes.Buffer{}
+	err := Render(findTestGaps(t, Settings{MaxGap: time.Hour}), &buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buffer.String(), "Fri 2024-04-26  missing  nothing tracked") {
+		t.Errorf("Unexpected output:\n%s", buffer.String())
+	}
+}
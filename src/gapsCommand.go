@@ -0,0 +1,58 @@
+package main
+
+import (
+	"time"
+	"timetrack-sync/src/gaps"
+)
+
+func runGaps(app *app, args []string) int {
+	logger := app.logger
+	flagSet := app.newFlagSet("gaps", "gaps [flags]")
+	flags := registerRangeFlags(flagSet, app.now())
+	minHours := flagSet.Float64("min-hours", 0, "Hours a working day needs at least. Defaults to the hours expected by the contract.")
+	maxGap := flagSet.Duration("max-gap", time.Hour, "Longest untracked pause between entries of a day, 0 disables the check.")
+
+	if code, ok := parseFlags(flagSet, args); !ok {
+		return code
+	}
+
+	location, since, until, err := resolveRange(flags)
+	if err != nil {
+		logger.Error().Err(err).Msg("Invalid range")
+		return exitUsage
+	}
+
+	workContract, err := app.contract()
+	if err != nil {
+		logger.Error().Err(err).Msg("Invalid contract")
+		return exitUsage
+	}
+
+	calendar, err := app.holidays()
+	if err != nil {
+		logger.Error().Err(err).Msg("Invalid holiday calendar")
+		return exitUsage
+	}
+
+	account := app.defaultAccount("")
+	// entries started the day before may cross midnight into the range, Find clips them
+	entries, err := app.togglClient(account, logger).GetTimeEntries(since.AddDate(0, 0, -1), until)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error while fetching Toggl entries")
+		return exitFailure
+	}
+
+	settings := gaps.Settings{MinHours: *minHours, MaxGap: *maxGap}
+	found := gaps.Find(entries, workContract, calendar, settings, since, until, app.now(), location)
+	err = gaps.Render(found, app.stdout)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error while rendering gaps")
+		return exitFailure
+	}
+
+	if len(found) > 0 {
+		return exitProblemsFound
+	}
+
+	return exitOk
+}
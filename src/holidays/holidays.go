@@ -0,0 +1,244 @@
+package holidays
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Country codes of built-in calendars.
+const (
+	CountryNone  = "none"
+	CountryCzech = "CZ"
+)
+
+// Holiday is a single free day, dates are YYYY-MM-DD in the JSON files.
+type Holiday struct {
+	Date string `json:"date"`
+	Name string `json:"name"`
+}
+
+// Lookup tells whether a day is a public holiday and its name, Calendar implements it.
+type Lookup interface {
+	Holiday(day time.Time) (string, bool)
+}
+
+// Calendar combines built-in public holidays of a country with days loaded from files.
+// It is safe for concurrent use.
+type Calendar struct {
+	country string
+	mutex   sync.Mutex
+	// days are keyed by YYYY-MM-DD, built-in years are added once they are first asked for
+	days      map[string]string
+	seenYears map[int]bool
+}
+
+// CreateCalendar creates a calendar with built-in holidays of the country, CountryNone or ""
+// gives an empty one.
+func CreateCalendar(country string) (*Calendar, error) {
+	country = strings.ToUpper(country)
+	if country != "" && country != strings.ToUpper(CountryNone) && country != CountryCzech {
+		return nil, fmt.Errorf("no built-in holidays for country %q", country)
+	}
+	if country == strings.ToUpper(CountryNone) {
+		country = ""
+	}
+
+	return &Calendar{country: country, days: map[string]string{}, seenYears: map[int]bool{}}, nil
+}
+
+// Add marks the day as a holiday.
+func (calendar *Calendar) Add(day time.Time, name string) {
+	calendar.mutex.Lock()
+	defer calendar.mutex.Unlock()
+
+	calendar.days[day.Format(time.DateOnly)] = name
+}
+
+// Holiday reports whether the day is a holiday, the date of day is used regardless of its location.
+func (calendar *Calendar) Holiday(day time.Time) (string, bool) {
+	calendar.mutex.Lock()
+	defer calendar.mutex.Unlock()
+
+	if calendar.country == CountryCzech && !calendar.seenYears[day.Year()] {
+		calendar.seenYears[day.Year()] = true
+		for _, holiday := range Czech(day.Year()) {
+			if _, ok := calendar.days[holiday.Date]; !ok {
+				calendar.days[holiday.Date] = holiday.Name
+			}
+		}
+	}
+
+	name, ok := calendar.days[day.Format(time.DateOnly)]
+	return name, ok
+}
+
+// Czech returns public holidays of the Czech Republic in the year.
+func Czech(year int) []Holiday {
+	date := func(month time.Month, day int) string {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Format(time.DateOnly)
+	}
+	easter := EasterSunday(year)
+
+	holidays := []Holiday{
+		{date(time.January, 1), "Den obnovy samostatného českého státu"},
+		{easter.AddDate(0, 0, 1).Format(time.DateOnly), "Velikonoční pondělí"},
+		{date(time.May, 1), "Svátek práce"},
+		{date(time.May, 8), "Den vítězství"},
+		{date(time.July, 5), "Den slovanských věrozvěstů Cyrila a Metoděje"},
+		{date(time.July, 6), "Den upálení mistra Jana Husa"},
+		{date(time.September, 28), "Den české státnosti"},
+		{date(time.October, 28), "Den vzniku samostatného československého státu"},
+		{date(time.November, 17), "Den boje za svobodu a demokracii"},
+		{date(time.December, 24), "Štědrý den"},
+		{date(time.December, 25), "1. svátek vánoční"},
+		{date(time.December, 26), "2. svátek vánoční"},
+	}
+	// Good Friday is a holiday since 2016
+	if year >= 2016 {
+		holidays = append(holidays, Holiday{easter.AddDate(0, 0, -2).Format(time.DateOnly), "Velký pátek"})
+	}
+
+	return holidays
+}
+
+// EasterSunday computes the Gregorian Easter Sunday with the anonymous Gregorian algorithm.
+func EasterSunday(year int) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+// LoadFile adds holidays of a .json file with a list of Holiday objects or of an .ics calendar.
+func (calendar *Calendar) LoadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var holidays []Holiday
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.NewDecoder(file).Decode(&holidays)
+	case ".ics":
+		holidays, err = ParseIcs(file)
+	default:
+		return fmt.Errorf("unsupported holiday file %s, expected .json or .ics", path)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid holiday file %s: %w", path, err)
+	}
+
+	for _, holiday := range holidays {
+		day, err := time.Parse(time.DateOnly, holiday.Date)
+		if err != nil {
+			return fmt.Errorf("invalid holiday file %s: %w", path, err)
+		}
+		calendar.Add(day, holiday.Name)
+	}
+
+	return nil
+}
+
+// ParseIcs reads all-day and timed VEVENTs of an iCalendar file. Events spanning several days
+// (DTEND is exclusive) give a holiday for each of them.
+func ParseIcs(reader io.Reader) ([]Holiday, error) {
+	lines, err := unfoldIcs(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	holidays := []Holiday{}
+	inEvent := false
+	var start, end time.Time
+	var summary string
+	for _, line := range lines {
+		name, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		property, _, _ := strings.Cut(name, ";")
+
+		switch strings.ToUpper(property) {
+		case "BEGIN":
+			if strings.EqualFold(value, "VEVENT") {
+				inEvent, start, end, summary = true, time.Time{}, time.Time{}, ""
+			}
+		case "DTSTART":
+			if inEvent {
+				start, err = parseIcsDate(value)
+			}
+		case "DTEND":
+			if inEvent {
+				end, err = parseIcsDate(value)
+			}
+		case "SUMMARY":
+			if inEvent {
+				summary = strings.NewReplacer(`\,`, ",", `\;`, ";", `\n`, " ", `\\`, `\`).Replace(value)
+			}
+		case "END":
+			if !inEvent || !strings.EqualFold(value, "VEVENT") {
+				continue
+			}
+			inEvent = false
+			if start.IsZero() {
+				return nil, fmt.Errorf("event %q without DTSTART", summary)
+			}
+
+			holidays = append(holidays, Holiday{Date: start.Format(time.DateOnly), Name: summary})
+			for day := start.AddDate(0, 0, 1); day.Before(end); day = day.AddDate(0, 0, 1) {
+				holidays = append(holidays, Holiday{Date: day.Format(time.DateOnly), Name: summary})
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return holidays, nil
+}
+
+// unfoldIcs joins continuation lines, which start with a space or a tab.
+func unfoldIcs(reader io.Reader) ([]string, error) {
+	lines := []string{}
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, scanner.Err()
+}
+
+// parseIcsDate keeps only the date of DATE and DATE-TIME values.
+func parseIcsDate(value string) (time.Time, error) {
+	if len(value) < 8 {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+
+	return time.Parse("20060102", value[:8])
+}
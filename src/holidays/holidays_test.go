@@ -0,0 +1,104 @@
+package holidays
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+	testutils "timetrack-sync/src/testUtils"
+)
+
+func TestEasterSunday(t *testing.T) {
+	expected := map[int]string{
+		2000: "2000-04-23",
+		2019: "2019-04-21",
+		2024: "2024-03-31",
+		2025: "2025-04-20",
+		2038: "2038-04-25",
+	}
+
+	for year, date := range expected {
+		actual := EasterSunday(year).Format(time.DateOnly)
+		if actual != date {
+			t.Errorf("%d: expected %s, got %s", year, date, actual)
+		}
+	}
+}
+
+func TestCzechHolidays(t *testing.T) {
+	calendar, err := CreateCalendar("cz")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, date := range []string{"2024-01-01", "2024-03-29", "2024-04-01", "2024-05-08", "2024-07-05", "2024-09-28", "2024-11-17", "2024-12-26"} {
+		if _, ok := calendar.Holiday(testutils.DateFromString(date)); !ok {
+			t.Errorf("expected %s to be a holiday", date)
+		}
+	}
+	for _, date := range []string{"2024-03-31", "2024-04-02", "2024-12-31"} {
+		if name, ok := calendar.Holiday(testutils.DateFromString(date)); ok {
+			t.Errorf("expected %s to be a workday, got %s", date, name)
+		}
+	}
+
+	name, _ := calendar.Holiday(testutils.DateFromString("2025-04-21"))
+	if name != "Velikonoční pondělí" {
+		t.Errorf("expected Easter Monday, got %q", name)
+	}
+	// Good Friday was not a holiday before 2016
+	if _, ok := calendar.Holiday(testutils.DateFromString("2015-04-03")); ok {
+		t.Error("expected Good Friday 2015 to be a workday")
+	}
+}
+
+func TestHolidayUsesDateInAnyLocation(t *testing.T) {
+	calendar, _ := CreateCalendar(CountryCzech)
+	prague, _ := time.LoadLocation("Europe/Prague")
+
+	if _, ok := calendar.Holiday(time.Date(2024, 5, 1, 0, 30, 0, 0, prague)); !ok {
+		t.Error("expected May 1 in Prague to be a holiday")
+	}
+}
+
+func TestLoadFiles(t *testing.T) {
+	calendar, err := CreateCalendar(CountryNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"company.ics", "company.json"} {
+		err = calendar.LoadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := map[string]string{
+		"2024-12-27": "Company shutdown, end of year",
+		"2024-12-30": "Company shutdown, end of year",
+		"2024-06-14": "Teambuilding",
+		"2024-12-31": "Silvestr",
+	}
+	for date, name := range expected {
+		actual, ok := calendar.Holiday(testutils.DateFromString(date))
+		if !ok || actual != name {
+			t.Errorf("%s: expected %q, got %q", date, name, actual)
+		}
+	}
+	if _, ok := calendar.Holiday(testutils.DateFromString("2024-01-01")); ok {
+		t.Error("expected no built-in holidays")
+	}
+}
+
+func TestUnknownCountryAndFile(t *testing.T) {
+	_, err := CreateCalendar("DE")
+	if err == nil {
+		t.Error("expected an error for unknown country")
+	}
+
+	calendar, _ := CreateCalendar("")
+	err = calendar.LoadFile("holidays.csv")
+	if err == nil {
+		t.Error("expected an error for unsupported file")
+	}
+}
@@ -0,0 +1,78 @@
+package httpfixture
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// MissingFields reports fields of target that are not present in the JSON data, with paths like
+// "data[0].planning_event.name". Keys are compared case-sensitively, so a mistyped or missing
+// json tag is reported even though encoding/json would still decode the field. Fields tagged
+// omitempty are optional.
+func MissingFields(data []byte, target any) ([]string, error) {
+	var decoded any
+	err := json.Unmarshal(data, &decoded)
+	if err != nil {
+		return nil, err
+	}
+
+	missing := []string{}
+	collectMissing(decoded, reflect.TypeOf(target), "", &missing)
+	return missing, nil
+}
+
+func collectMissing(value any, valueType reflect.Type, path string, missing *[]string) {
+	for valueType.Kind() == reflect.Pointer {
+		valueType = valueType.Elem()
+	}
+
+	switch valueType.Kind() {
+	case reflect.Slice, reflect.Array:
+		items, ok := value.([]any)
+		if !ok {
+			return
+		}
+		for i, item := range items {
+			collectMissing(item, valueType.Elem(), fmt.Sprintf("%s[%d]", path, i), missing)
+		}
+	case reflect.Struct:
+		object, ok := value.(map[string]any)
+		if !ok || valueType == timeType {
+			return
+		}
+
+		for i := 0; i < valueType.NumField(); i++ {
+			field := valueType.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+
+			fieldPath := name
+			if path != "" {
+				fieldPath = path + "." + name
+			}
+
+			fieldValue, found := object[name]
+			if !found {
+				if !strings.Contains(options, "omitempty") {
+					*missing = append(*missing, fieldPath)
+				}
+				continue
+			}
+			collectMissing(fieldValue, field.Type, fieldPath, missing)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+// Package httpfixture records HTTP exchanges of the API clients to sanitized fixture files and
+// replays them in tests, so real response shapes can be checked against the client types.
+package httpfixture
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"timetrack-sync/src/redact"
+)
+
+var ErrNoExchange = errors.New("no recorded exchange matches the request")
+
+type Request struct {
+	Method string `json:"method"`
+	// Url is the path with the query, the host is not recorded
+	Url  string          `json:"url"`
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+type Response struct {
+	Status      int             `json:"status"`
+	ContentType string          `json:"content_type,omitempty"`
+	Body        json.RawMessage `json:"body,omitempty"`
+}
+
+type Exchange struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Read loads exchanges from a fixture file.
+func Read(path string) ([]Exchange, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var exchanges []Exchange
+	err = json.Unmarshal(content, &exchanges)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fixture %s: %w", path, err)
+	}
+
+	return exchanges, nil
+}
+
+func write(path string, exchanges []Exchange) error {
+	content, err := json.MarshalIndent(exchanges, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(content, '\n'), 0600)
+}
+
+// requestKey identifies a request regardless of the host and order of query parameters.
+func requestKey(method string, requestUrl *url.URL) string {
+	return method + " " + requestUrl.Path + "?" + requestUrl.Query().Encode()
+}
+
+// encodeBody keeps JSON bodies readable in the fixture, other bodies are stored as JSON strings.
+func encodeBody(body []byte) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+	if json.Valid(body) {
+		return body
+	}
+
+	encoded, _ := json.Marshal(string(body))
+	return encoded
+}
+
+// decodeBody unwraps bodies encodeBody stored as JSON strings, JSON responses are replayed as recorded.
+func decodeBody(body json.RawMessage, contentType string) []byte {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	var text string
+	if mediaType != "application/json" && json.Unmarshal(body, &text) == nil {
+		return []byte(text)
+	}
+
+	return body
+}
+
+// Recorder passes requests to Transport and records every exchange to the fixture file at Path.
+// Headers are never recorded, bodies and URLs pass the redactor before they are written.
+type Recorder struct {
+	Transport http.RoundTripper
+	Path      string
+	Redactor  *redact.Writer
+
+	mutex     sync.Mutex
+	exchanges []Exchange
+}
+
+// CreateRecorder records through http.DefaultTransport, masking default sensitive fields and given secrets.
+func CreateRecorder(path string, secrets ...string) *Recorder {
+	redactor := redact.CreateWriter(io.Discard, redact.DefaultFields...)
+	redactor.AddSecret(secrets...)
+	return &Recorder{Transport: http.DefaultTransport, Path: path, Redactor: redactor}
+}
+
+func (recorder *Recorder) RoundTrip(request *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if request.Body != nil {
+		var err error
+		requestBody, err = io.ReadAll(request.Body)
+		request.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		request.Body = io.NopCloser(bytes.NewReader(requestBody))
+	}
+
+	response, err := recorder.Transport.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+
+	responseBody, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = io.NopCloser(bytes.NewReader(responseBody))
+
+	exchange := Exchange{
+		Request: Request{
+			Method: request.Method,
+			Url:    string(recorder.Redactor.Redact([]byte(request.URL.RequestURI()))),
+			Body:   encodeBody(recorder.Redactor.Redact(requestBody)),
+		},
+		Response: Response{
+			Status:      response.StatusCode,
+			ContentType: response.Header.Get("Content-Type"),
+			Body:        encodeBody(recorder.Redactor.Redact(responseBody)),
+		},
+	}
+
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	recorder.exchanges = append(recorder.exchanges, exchange)
+	// written after every exchange, a recording run may end with an error or signal
+	err = write(recorder.Path, recorder.exchanges)
+	if err != nil {
+		return nil, fmt.Errorf("error while writing fixture: %w", err)
+	}
+
+	return response, nil
+}
+
+// Replayer answers requests with recorded responses. Exchanges of the same request are
+// replayed in the recorded order, the last one is repeated once the others are used.
+type Replayer struct {
+	mutex     sync.Mutex
+	exchanges map[string][]Exchange
+}
+
+func CreateReplayer(exchanges []Exchange) (*Replayer, error) {
+	replayer := &Replayer{exchanges: map[string][]Exchange{}}
+	for _, exchange := range exchanges {
+		requestUrl, err := url.Parse(exchange.Request.Url)
+		if err != nil {
+			return nil, err
+		}
+
+		key := requestKey(exchange.Request.Method, requestUrl)
+		replayer.exchanges[key] = append(replayer.exchanges[key], exchange)
+	}
+
+	return replayer, nil
+}
+
+// ReadReplayer creates a replayer of the fixture file.
+func ReadReplayer(path string) (*Replayer, error) {
+	exchanges, err := Read(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return CreateReplayer(exchanges)
+}
+
+func (replayer *Replayer) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request.Body != nil {
+		request.Body.Close()
+	}
+
+	replayer.mutex.Lock()
+	defer replayer.mutex.Unlock()
+
+	key := requestKey(request.Method, request.URL)
+	exchanges := replayer.exchanges[key]
+	if len(exchanges) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoExchange, key)
+	}
+
+	exchange := exchanges[0]
+	if len(exchanges) > 1 {
+		replayer.exchanges[key] = exchanges[1:]
+	}
+
+	header := http.Header{}
+	if exchange.Response.ContentType != "" {
+		header.Set("Content-Type", exchange.Response.ContentType)
+	}
+	body := decodeBody(exchange.Response.Body, exchange.Response.ContentType)
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", exchange.Response.Status, http.StatusText(exchange.Response.Status)),
+		StatusCode:    exchange.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       request,
+	}, nil
+}
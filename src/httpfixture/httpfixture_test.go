@@ -0,0 +1,132 @@
+package httpfixture
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		body, _ := io.ReadAll(request.Body)
+		writer.Write([]byte(`{"api_token":"abcdef123456","echo":` + string(body) + `,"path":"` + request.URL.Path + `"}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "exchanges.json")
+	recorder := CreateRecorder(path, "secret-bearer")
+	client := &http.Client{Transport: recorder}
+
+	request, _ := http.NewRequest(http.MethodPost, server.URL+"/items?b=2&a=1", strings.NewReader(`{"note":"secret-bearer"}`))
+	request.Header.Set("Authorization", "Bearer secret-bearer")
+	response, err := client.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	live, _ := io.ReadAll(response.Body)
+	if !strings.Contains(string(live), "abcdef123456") {
+		t.Errorf("the live response must not be altered, got %s", live)
+	}
+
+	content, _ := os.ReadFile(path)
+	for _, leaked := range []string{"abcdef123456", "secret-bearer", "Authorization", server.URL} {
+		if strings.Contains(string(content), leaked) {
+			t.Errorf("fixture contains %q: %s", leaked, content)
+		}
+	}
+
+	replayer, err := ReadReplayer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client = &http.Client{Transport: replayer}
+
+	// host and order of query parameters do not matter
+	response, err = client.Post("http://replayed.invalid/items?a=1&b=2", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed, _ := io.ReadAll(response.Body)
+	if response.StatusCode != http.StatusOK || response.Header.Get("Content-Type") != "application/json" || !strings.Contains(string(replayed), `"/items"`) {
+		t.Errorf("unexpected replayed response %d %s", response.StatusCode, replayed)
+	}
+
+	_, err = client.Get("http://replayed.invalid/other")
+	if !errors.Is(err, ErrNoExchange) {
+		t.Errorf("expected ErrNoExchange, got %v", err)
+	}
+}
+
+func TestReplayInRecordedOrder(t *testing.T) {
+	replayer, err := CreateReplayer([]Exchange{
+		{Request: Request{Method: "GET", Url: "/me"}, Response: Response{Status: 500}},
+		{Request: Request{Method: "GET", Url: "/me"}, Response: Response{Status: 200, ContentType: "text/plain", Body: []byte(`"fine"`)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: replayer}
+
+	for _, expected := range []int{500, 200, 200} {
+		response, err := client.Get("http://replayed.invalid/me")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if response.StatusCode != expected {
+			t.Errorf("expected %d, got %d", expected, response.StatusCode)
+		}
+		if expected == 200 {
+			body, _ := io.ReadAll(response.Body)
+			if string(body) != "fine" {
+				t.Errorf("expected plain body, got %q", body)
+			}
+		}
+	}
+}
+
+func TestReplayKeepsJsonStringWithCharset(t *testing.T) {
+	replayer, err := CreateReplayer([]Exchange{
+		{Request: Request{Method: "POST", Url: "/login"}, Response: Response{Status: 401, ContentType: "application/json; charset=utf-8", Body: []byte(`"Incorrect username and/or password"`)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: replayer}
+
+	response, err := client.Post("http://replayed.invalid/login", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(response.Body)
+	if string(body) != `"Incorrect username and/or password"` {
+		t.Errorf("expected the JSON string as recorded, got %q", body)
+	}
+}
+
+func TestMissingFields(t *testing.T) {
+	type event struct {
+		Name string `json:"name"`
+	}
+	type payload struct {
+		Id       string  `json:"id"`
+		Note     string  `json:"note,omitempty"`
+		Events   []event `json:"events"`
+		Mistyped string  `jons:"mistyped"`
+	}
+
+	missing, err := MissingFields([]byte(`{"id":"1","mistyped":"x","events":[{"name":"a"},{"title":"b"}]}`), payload{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"events[1].name", "Mistyped"}
+	if strings.Join(missing, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected %v, got %v", expected, missing)
+	}
+}
@@ -0,0 +1,136 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"timetrack-sync/src/redact"
+
+	"github.com/rs/zerolog"
+)
+
+var ErrInvalidOption = errors.New("invalid logging option")
+
+const (
+	FormatConsole = "console"
+	FormatJson    = "json"
+)
+
+// Options configure the logger shared by all commands.
+type Options struct {
+	Level      zerolog.Level
+	Format     string
+	File       string
+	MaxSizeMB  int
+	MaxBackups int
+}
+
+func DefaultOptions() Options {
+	return Options{Level: zerolog.InfoLevel, Format: FormatConsole, MaxSizeMB: 10, MaxBackups: 5}
+}
+
+// Usage describes the global logging flags.
+const Usage = `Logging flags, accepted before or after the command:
+  --log-level LEVEL       trace, debug, info, warn or error (default info)
+  --log-format FORMAT     console or json (default console)
+  --log-file PATH         write logs to the file instead of stderr
+  --log-max-size MB       rotate the log file at this size, 0 disables rotation (default 10)
+  --log-max-backups N     number of rotated log files kept (default 5)`
+
+// ExtractFlags removes the logging flags from args, so every command accepts them without
+// registering them in its own flag set. Arguments after "--" are left untouched.
+func ExtractFlags(args []string) (Options, []string, error) {
+	options := DefaultOptions()
+	rest := make([]string, 0, len(args))
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || !strings.HasPrefix(name, "log-") {
+			rest = append(rest, arg)
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return options, nil, fmt.Errorf("%w: flag needs an argument: %s", ErrInvalidOption, arg)
+			}
+			i++
+			value = args[i]
+		}
+
+		err := options.set(name, value)
+		if err != nil {
+			return options, nil, err
+		}
+	}
+
+	return options, rest, nil
+}
+
+func (options *Options) set(name string, value string) error {
+	var err error
+	switch name {
+	case "log-level":
+		level, parseErr := zerolog.ParseLevel(strings.ToLower(value))
+		if parseErr != nil || value == "" {
+			err = errors.New("expected trace, debug, info, warn or error")
+		}
+		options.Level = level
+	case "log-format":
+		if value != FormatConsole && value != FormatJson {
+			err = errors.New("expected console or json")
+		}
+		options.Format = value
+	case "log-file":
+		options.File = value
+	case "log-max-size":
+		options.MaxSizeMB, err = strconv.Atoi(value)
+		if err == nil && options.MaxSizeMB < 0 {
+			err = errors.New("negative size")
+		}
+	case "log-max-backups":
+		options.MaxBackups, err = strconv.Atoi(value)
+		if err == nil && options.MaxBackups < 0 {
+			err = errors.New("negative count")
+		}
+	default:
+		return fmt.Errorf("%w: unknown flag --%s", ErrInvalidOption, name)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: --%s %q: %v", ErrInvalidOption, name, value, err)
+	}
+	return nil
+}
+
+// Setup creates the logger writing to stderr or the log file. Every line passes the redactor,
+// which is returned so secrets can be registered as they are read. The closer releases the log file.
+func Setup(options Options, stderr io.Writer) (zerolog.Logger, *redact.Writer, io.Closer, error) {
+	var output io.Writer = stderr
+	var closer io.Closer = io.NopCloser(nil)
+	if options.File != "" {
+		file, err := OpenRotatingFile(options.File, int64(options.MaxSizeMB)*1024*1024, options.MaxBackups)
+		if err != nil {
+			return zerolog.Nop(), nil, nil, err
+		}
+		output, closer = file, file
+	}
+
+	if options.Format == FormatConsole {
+		_, isFile := output.(*os.File)
+		output = zerolog.ConsoleWriter{Out: output, TimeFormat: time.StampMilli, NoColor: options.File != "" || !isFile}
+	}
+
+	redactor := redact.CreateWriter(output)
+	logger := zerolog.New(redactor).With().Timestamp().Logger().Level(options.Level)
+	return logger, redactor, closer, nil
+}
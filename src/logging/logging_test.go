@@ -0,0 +1,180 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestExtractFlags(t *testing.T) {
+	args := []string{"--log-level", "debug", "daemon", "-interval", "5m", "-log-format=json", "--log-file=sync.log", "--", "--log-level", "x"}
+
+	options, rest, err := ExtractFlags(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if options.Level != zerolog.DebugLevel || options.Format != FormatJson || options.File != "sync.log" {
+		t.Errorf("unexpected options %+v", options)
+	}
+	expected := []string{"daemon", "-interval", "5m", "--", "--log-level", "x"}
+	if !slices.Equal(rest, expected) {
+		t.Errorf("expected %v, got %v", expected, rest)
+	}
+}
+
+func TestExtractFlagsDefaults(t *testing.T) {
+	options, rest, err := ExtractFlags([]string{"sync", "-dry-run"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if options != DefaultOptions() {
+		t.Errorf("expected defaults, got %+v", options)
+	}
+	if len(rest) != 2 {
+		t.Errorf("expected arguments untouched, got %v", rest)
+	}
+}
+
+func TestExtractFlagsInvalid(t *testing.T) {
+	for _, args := range [][]string{
+		{"--log-level", "loud"},
+		{"--log-format=xml"},
+		{"--log-max-size", "-1"},
+		{"--log-file"},
+		{"--log-colour=on"},
+	} {
+		_, _, err := ExtractFlags(args)
+		if !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("%v: expected ErrInvalidOption, got %v", args, err)
+		}
+	}
+}
+
+func TestSetupJsonToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sync.log")
+	options := DefaultOptions()
+	options.Format = FormatJson
+	options.File = path
+	options.Level = zerolog.WarnLevel
+
+	logger, redactor, closer, err := Setup(options, &bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	redactor.AddSecret("very-secret")
+
+	logger.Info().Msg("hidden by level")
+	logger.Warn().Str("note", "very-secret").Msg("visible")
+	closer.Close()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected one line, got %q", content)
+	}
+
+	var event map[string]any
+	err = json.Unmarshal([]byte(lines[0]), &event)
+	if err != nil {
+		t.Fatalf("log line is not JSON: %v", err)
+	}
+	if event["message"] != "visible" || event["level"] != "warn" || event["note"] != "[REDACTED]" {
+		t.Errorf("unexpected event %v", event)
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sync.log")
+	file, err := OpenRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err = file.Write([]byte(line))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := map[string]string{
+		path:        "fourth\n",
+		path + ".1": "third\n",
+		path + ".2": "second\n",
+	}
+	for name, content := range expected {
+		actual, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(actual) != content {
+			t.Errorf("%s: expected %q, got %q", name, content, actual)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected only two backups, got %v", err)
+	}
+}
+
+func TestRotatingFileKeepsWritingAfterFailedRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sync.log")
+	file, err := OpenRotatingFile(path, 10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	// a non-empty directory in place of the backup cannot be removed
+	os.MkdirAll(filepath.Join(path+".1", "blocked"), 0700)
+	for _, line := range []string{"first\n", "second\n"} {
+		_, err = file.Write([]byte(line))
+		if err != nil {
+			t.Fatalf("write after failed rotation: %v", err)
+		}
+	}
+	content, _ := os.ReadFile(path)
+	if string(content) != "first\nsecond\n" {
+		t.Errorf("expected lines kept in the current file, got %q", content)
+	}
+
+	os.RemoveAll(path + ".1")
+	_, err = file.Write([]byte("third\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	backup, _ := os.ReadFile(path + ".1")
+	content, _ = os.ReadFile(path)
+	if string(backup) != "first\nsecond\n" || string(content) != "third\n" {
+		t.Errorf("expected rotation once possible, got %q and %q", backup, content)
+	}
+}
+
+func TestRotatingFileAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sync.log")
+	os.WriteFile(path, []byte("old\n"), 0600)
+
+	file, err := OpenRotatingFile(path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Write([]byte("new\n"))
+	file.Close()
+
+	content, _ := os.ReadFile(path)
+	if string(content) != "old\nnew\n" {
+		t.Errorf("expected appended content, got %q", content)
+	}
+}
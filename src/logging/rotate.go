@@ -0,0 +1,125 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// RotatingFile is a log file that is renamed to path.1 once it grows over the maximum size,
+// older files shift to path.2 and so on up to the number of kept backups.
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	mutex      sync.Mutex
+	file       *os.File
+	size       int64
+	closed     bool
+}
+
+// OpenRotatingFile appends to the file at path, maxSize 0 disables rotation.
+func OpenRotatingFile(path string, maxSize int64, maxBackups int) (*RotatingFile, error) {
+	rotating := &RotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	err := rotating.open()
+	if err != nil {
+		return nil, err
+	}
+
+	return rotating, nil
+}
+
+func (rotating *RotatingFile) open() error {
+	file, err := os.OpenFile(rotating.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	rotating.file = file
+	rotating.size = info.Size()
+	return nil
+}
+
+func (rotating *RotatingFile) Write(line []byte) (int, error) {
+	rotating.mutex.Lock()
+	defer rotating.mutex.Unlock()
+
+	if rotating.closed {
+		return 0, fs.ErrClosed
+	}
+
+	if rotating.maxSize > 0 && rotating.size > 0 && rotating.size+int64(len(line)) > rotating.maxSize {
+		// a failed rotation must not stop logging, it is retried with the next line
+		rotating.rotate()
+	}
+	// the file is closed when the rotation failed half way, lines go to path until it succeeds
+	if rotating.file == nil {
+		err := rotating.open()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	written, err := rotating.file.Write(line)
+	rotating.size += int64(written)
+	return written, err
+}
+
+func (rotating *RotatingFile) rotate() error {
+	if rotating.file != nil {
+		err := rotating.file.Close()
+		rotating.file = nil
+		if err != nil {
+			return err
+		}
+	}
+
+	var err error
+
+	if rotating.maxBackups == 0 {
+		err = os.Remove(rotating.path)
+	} else {
+		err = os.Remove(backupPath(rotating.path, rotating.maxBackups))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		for i := rotating.maxBackups - 1; i >= 1; i-- {
+			err = os.Rename(backupPath(rotating.path, i), backupPath(rotating.path, i+1))
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+		}
+		err = os.Rename(rotating.path, backupPath(rotating.path, 1))
+	}
+	if err != nil {
+		return err
+	}
+
+	return rotating.open()
+}
+
+func (rotating *RotatingFile) Close() error {
+	rotating.mutex.Lock()
+	defer rotating.mutex.Unlock()
+
+	rotating.closed = true
+	if rotating.file == nil {
+		return nil
+	}
+
+	err := rotating.file.Close()
+	rotating.file = nil
+	return err
+}
+
+func backupPath(path string, index int) string {
+	return fmt.Sprintf("%s.%d", path, index)
+}
@@ -0,0 +1,454 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"timetrack-sync/src/fakeapi"
+)
+
+type fakeServices struct {
+	toggl   *fakeapi.Toggl
+	sloneek *fakeapi.Sloneek
+	env     []string
+	// stdin answers prompts, e.g. of the interactive review
+	stdin string
+	// now overrides the clock of noon 2024-03-20 UTC
+	now time.Time
+}
+
+func startFakeServices(t *testing.T) *fakeServices {
+	t.Helper()
+	proteus, meetings := int32(1), int32(3)
+	toggl := fakeapi.CreateToggl(fakeapi.TogglState{
+		ApiKey:      "toggl-key",
+		WorkspaceId: 10,
+		Projects:    []fakeapi.TogglProject{{Id: proteus, Name: "Proteus"}, {Id: meetings, Name: "Admin & Meetings"}},
+		TimeEntries: []fakeapi.TogglTimeEntry{
+			{Id: 1, ProjectId: &proteus, Start: time.Date(2024, 3, 4, 8, 2, 0, 0, time.UTC), Stop: time.Date(2024, 3, 4, 11, 58, 0, 0, time.UTC), Description: "API"},
+			{Id: 2, ProjectId: &meetings, Start: time.Date(2024, 3, 5, 12, 30, 0, 0, time.UTC), Stop: time.Date(2024, 3, 5, 13, 0, 0, 0, time.UTC), Description: "Standup"},
+			{Id: 3, ProjectId: &proteus, Start: time.Date(2024, 4, 2, 9, 0, 0, 0, time.UTC), Stop: time.Date(2024, 4, 2, 10, 0, 0, 0, time.UTC), Description: "April"},
+		},
+	})
+	sloneek := fakeapi.CreateSloneek(fakeapi.SloneekState{
+		Bearer:     "jwt",
+		Categories: []fakeapi.SloneekCategory{{Uuid: "category-proteus", Name: "Proteus"}},
+		Activities: []fakeapi.SloneekActivity{
+			{Uuid: "activity-development", Name: "Vývoj"},
+			{Uuid: "activity-meeting", Name: "Meeting"},
+		},
+	})
+
+	togglServer := httptest.NewServer(toggl)
+	t.Cleanup(togglServer.Close)
+	sloneekServer := httptest.NewServer(sloneek)
+	t.Cleanup(sloneekServer.Close)
+
+	return &fakeServices{
+		toggl:   toggl,
+		sloneek: sloneek,
+		env: []string{
+			"TOGGL_API_URL=" + togglServer.URL + fakeapi.TogglPrefix,
+			"SLONEEK_API=" + sloneekServer.URL,
+			"TOGGL_API_KEY=toggl-key",
+			"USER_UUID=user-1",
+		},
+	}
+}
+
+func (services *fakeServices) run(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+	return services.runWithContext(context.Background(), args...)
+}
+
+// runWithContext lets long running commands like daemon be stopped by canceling the context.
+func (services *fakeServices) runWithContext(ctx context.Context, args ...string) (int, string, string) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	now := services.now
+	if now.IsZero() {
+		now = time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC)
+	}
+	clock := func() time.Time { return now }
+
+	code := Run(ctx, args, services.env, stdout, stderr, WithClock(clock), WithStdin(strings.NewReader(services.stdin)))
+	return code, stdout.String(), stderr.String()
+}
+
+func TestSyncSavesEntriesAndPrintsSummary(t *testing.T) {
+	services := startFakeServices(t)
+
+	code, stdout, stderr := services.run(t, "sync", "-bearer", "jwt", "-timezone", "UTC", "-since", "2024-03-01", "-until", "2024-04-01")
+	if code != exitOk {
+		t.Fatalf("expected exit %d, got %d: %s", exitOk, code, stderr)
+	}
+
+	events := services.sloneek.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected two saved events, got %+v", events)
+	}
+	// the plan creates entries in order of their start, entries are rounded before they are saved
+	development := events[0]
+	if development.ActivityUuid != "activity-development" || development.StartedAt.Minute() != 0 || development.EndedAt.Hour() != 12 || development.UserUuid != "user-1" {
+		t.Errorf("unexpected development event %+v", development)
+	}
+
+	for _, expected := range []string{"Vývoj", "Meeting", "4.50", "2024-W10"} {
+		if !strings.Contains(stdout, expected) {
+			t.Errorf("summary is missing %q:\n%s", expected, stdout)
+		}
+	}
+	if strings.Contains(stderr, "toggl-key") || strings.Contains(stderr, "jwt") {
+		t.Errorf("credentials leaked to logs:\n%s", stderr)
+	}
+}
+
+func TestRepeatedSyncDoesNotDuplicateEntries(t *testing.T) {
+	services := startFakeServices(t)
+
+	for i := 0; i < 2; i++ {
+		code, _, stderr := services.run(t, "sync", "-bearer", "jwt", "-timezone", "UTC", "-since", "2024-03-01", "-until", "2024-04-01")
+		if code != exitOk {
+			t.Fatalf("expected exit %d, got %d: %s", exitOk, code, stderr)
+		}
+	}
+
+	if len(services.sloneek.Events()) != 2 {
+		t.Errorf("expected the entries saved once, got %+v", services.sloneek.Events())
+	}
+}
+
+func TestDryRunPreviewsWithoutSaving(t *testing.T) {
+	services := startFakeServices(t)
+
+	code, stdout, stderr := services.run(t, "sync", "-dry-run", "-bearer", "jwt", "-timezone", "UTC", "-since", "2024-03-01", "-until", "2024-04-01")
+	if code != exitOk {
+		t.Fatalf("expected exit %d, got %d: %s", exitOk, code, stderr)
+	}
+
+	if len(services.sloneek.Events()) != 0 {
+		t.Errorf("dry run saved events %+v", services.sloneek.Events())
+	}
+	for _, expected := range []string{"08:00-12:00", "was 08:02-11:58", "Tue 2024-03-05", "Total"} {
+		if !strings.Contains(stdout, expected) {
+			t.Errorf("preview is missing %q:\n%s", expected, stdout)
+		}
+	}
+	// no terminal, no colors
+	if strings.Contains(stdout, "\x1b[") {
+		t.Errorf("expected plain output:\n%q", stdout)
+	}
+}
+
+func TestDefaultRangeIsMonthOfClock(t *testing.T) {
+	services := startFakeServices(t)
+
+	code, stdout, stderr := services.run(t, "report", "-bearer", "jwt", "-report-format", "csv")
+	if code != exitOk {
+		t.Fatalf("expected exit %d, got %d: %s", exitOk, code, stderr)
+	}
+
+	// the April entry is outside of March the clock is set to
+	if !strings.Contains(stdout, "total,,4.50") {
+		t.Errorf("expected March entries only:\n%s", stdout)
+	}
+}
+
+func TestDefaultRangeIsMonthInTimezone(t *testing.T) {
+	services := startFakeServices(t)
+	// already April in Prague
+	services.now = time.Date(2024, 3, 31, 22, 30, 0, 0, time.UTC)
+
+	code, stdout, stderr := services.run(t, "report", "-bearer", "jwt", "-timezone", "Europe/Prague", "-report-format", "csv")
+	if code != exitOk {
+		t.Fatalf("expected exit %d, got %d: %s", exitOk, code, stderr)
+	}
+	if !strings.Contains(stdout, "total,,1.00") {
+		t.Errorf("expected April entries only:\n%s", stdout)
+	}
+}
+
+func TestReconcileFindsMissingEntries(t *testing.T) {
+	services := startFakeServices(t)
+
+	code, stdout, _ := services.run(t, "reconcile", "-bearer", "jwt", "-timezone", "UTC")
+	if code != exitProblemsFound {
+		t.Errorf("expected exit %d, got %d", exitProblemsFound, code)
+	}
+	if !strings.Contains(stdout, "missing_in_sloneek") {
+		t.Errorf("expected missing entries:\n%s", stdout)
+	}
+
+	code, _, _ = services.run(t, "sync", "-bearer", "jwt", "-timezone", "UTC")
+	if code != exitOk {
+		t.Fatalf("sync failed with %d", code)
+	}
+	code, _, _ = services.run(t, "reconcile", "-bearer", "jwt", "-timezone", "UTC")
+	if code != exitOk {
+		t.Errorf("expected no differences after sync, got exit %d", code)
+	}
+}
+
+func TestFailuresExitCodes(t *testing.T) {
+	services := startFakeServices(t)
+
+	tests := []struct {
+		args     []string
+		expected int
+	}{
+		{[]string{"unknown"}, exitUsage},
+		{[]string{"sync", "-unknown-flag"}, exitUsage},
+		{[]string{"--log-level", "loud", "sync"}, exitUsage},
+		{[]string{"sync", "-h"}, exitOk},
+		{[]string{"sync", "-bearer", "expired"}, exitFailure},
+		// gaps reads Toggl only
+		{[]string{"gaps", "-bearer", "jwt"}, exitUsage},
+	}
+
+	for _, test := range tests {
+		code, _, _ := services.run(t, test.args...)
+		if code != test.expected {
+			t.Errorf("%v: expected exit %d, got %d", test.args, test.expected, code)
+		}
+	}
+}
+
+func TestAutoApprovalAndApprovalsReport(t *testing.T) {
+	services := startFakeServices(t)
+	policyPath := filepath.Join(t.TempDir(), "approval.json")
+	os.WriteFile(policyPath, []byte(`{"activities": {"Meeting": true}}`), 0600)
+	services.env = append(services.env, "TIMETRACK_APPROVAL_POLICY="+policyPath)
+
+	code, _, stderr := services.run(t, "sync", "-bearer", "jwt", "-timezone", "UTC")
+	if code != exitOk {
+		t.Fatalf("expected exit %d, got %d: %s", exitOk, code, stderr)
+	}
+	for _, event := range services.sloneek.Events() {
+		if event.IsAutomaticallyApprove != (event.ActivityUuid == "activity-meeting") {
+			t.Errorf("unexpected approval flag of %+v", event)
+		}
+	}
+
+	// entered in the Sloneek app, not synced from Toggl
+	services.sloneek.AddEvent(fakeapi.SloneekEvent{UserUuid: "user-1", ActivityUuid: "activity-meeting", StartedAt: time.Date(2024, 3, 6, 9, 0, 0, 0, time.UTC), EndedAt: time.Date(2024, 3, 6, 10, 0, 0, 0, time.UTC), Note: "Workshop"})
+
+	code, stdout, _ := services.run(t, "approvals", "-bearer", "jwt", "-timezone", "UTC", "-status", "pending")
+	if code != exitOk {
+		t.Fatalf("approvals failed with %d", code)
+	}
+	if !strings.Contains(stdout, "Vývoj") || strings.Contains(stdout, "Meeting") {
+		t.Errorf("expected only the pending synced development entry:\n%s", stdout)
+	}
+
+	code, stdout, _ = services.run(t, "approvals", "-bearer", "jwt", "-timezone", "UTC", "-status", "pending", "-all")
+	if code != exitOk {
+		t.Fatalf("approvals failed with %d", code)
+	}
+	if !strings.Contains(stdout, "2024-03-06  09:00-10:00  Meeting") {
+		t.Errorf("expected the hand-entered entry with -all:\n%s", stdout)
+	}
+}
+
+func TestPolicyViolationsBlockSync(t *testing.T) {
+	services := startFakeServices(t)
+	policyPath := filepath.Join(t.TempDir(), "policy.json")
+	os.WriteFile(policyPath, []byte(`{"max_hours_per_day": 3}`), 0600)
+	services.env = append(services.env, "TIMETRACK_WORK_POLICY="+policyPath, "TIMETRACK_POLICY_MODE=block")
+
+	code, stdout, _ := services.run(t, "validate", "-bearer", "jwt", "-timezone", "UTC")
+	if code != exitProblemsFound {
+		t.Errorf("expected exit %d, got %d", exitProblemsFound, code)
+	}
+	if !strings.Contains(stdout, "Mon 2024-03-04  max_hours") {
+		t.Errorf("expected the violation listed:\n%s", stdout)
+	}
+
+	code, _, _ = services.run(t, "sync", "-bearer", "jwt", "-timezone", "UTC")
+	if code != exitProblemsFound {
+		t.Errorf("expected exit %d, got %d", exitProblemsFound, code)
+	}
+	if len(services.sloneek.Events()) != 0 {
+		t.Errorf("blocked sync saved %+v", services.sloneek.Events())
+	}
+
+	// reading commands are not blocked by the policy
+	for _, command := range []string{"report", "reconcile"} {
+		code, _, stderr := services.run(t, command, "-bearer", "jwt", "-timezone", "UTC")
+		if code == exitFailure {
+			t.Errorf("%s failed on the policy: %s", command, stderr)
+		}
+	}
+}
+
+func TestPolicyViolationsArePreviewedInDryRun(t *testing.T) {
+	services := startFakeServices(t)
+	policyPath := filepath.Join(t.TempDir(), "policy.json")
+	os.WriteFile(policyPath, []byte(`{"max_hours_per_day": 3}`), 0600)
+	services.env = append(services.env, "TIMETRACK_WORK_POLICY="+policyPath, "TIMETRACK_POLICY_MODE=block")
+
+	code, stdout, stderr := services.run(t, "sync", "-dry-run", "-bearer", "jwt", "-timezone", "UTC")
+	if code != exitOk {
+		t.Fatalf("expected exit %d, got %d: %s", exitOk, code, stderr)
+	}
+	for _, expected := range []string{"08:00-12:00", "Mon 2024-03-04  max_hours"} {
+		if !strings.Contains(stdout, expected) {
+			t.Errorf("dry run is missing %q:\n%s", expected, stdout)
+		}
+	}
+}
+
+func TestPolicyIsCheckedAfterInteractiveReview(t *testing.T) {
+	services := startFakeServices(t)
+	policyPath := filepath.Join(t.TempDir(), "policy.json")
+	os.WriteFile(policyPath, []byte(`{"max_hours_per_day": 3}`), 0600)
+	services.env = append(services.env, "TIMETRACK_WORK_POLICY="+policyPath, "TIMETRACK_POLICY_MODE=block")
+
+	// accepted as mapped, the four hours of Monday are refused
+	services.stdin = "a\na\n"
+	code, _, _ := services.run(t, "sync", "-interactive", "-bearer", "jwt", "-timezone", "UTC")
+	if code != exitProblemsFound || len(services.sloneek.Events()) != 0 {
+		t.Fatalf("expected exit %d without events, got %d %+v", exitProblemsFound, code, services.sloneek.Events())
+	}
+
+	// shortened in the review, the day is within the policy
+	services.stdin = "e\n\n11:00\na\na\n"
+	code, _, stderr := services.run(t, "sync", "-interactive", "-bearer", "jwt", "-timezone", "UTC")
+	if code != exitOk {
+		t.Fatalf("expected exit %d, got %d: %s", exitOk, code, stderr)
+	}
+	events := services.sloneek.Events()
+	if len(events) != 2 || events[0].EndedAt.Hour() != 11 {
+		t.Errorf("expected the edited entry saved, got %+v", events)
+	}
+}
+
+func TestBalanceComparesHoursWithContract(t *testing.T) {
+	services := startFakeServices(t)
+
+	code, stdout, _ := services.run(t, "balance", "-bearer", "jwt", "-timezone", "UTC", "-source", "toggl", "-report-format", "csv")
+	if code != exitOk {
+		t.Fatalf("expected exit %d, got %d", exitOk, code)
+	}
+
+	// 13 workdays of March are over by the clock, the rest of the month expects nothing yet
+	for _, expected := range []string{"2024-03-04,8.00,4.00,-4.00,-12.00,", "total,104.00,4.50,-99.50,-99.50,"} {
+		if !strings.Contains(stdout, expected) {
+			t.Errorf("expected %q in:\n%s", expected, stdout)
+		}
+	}
+}
+
+func TestGapsListsDaysWithoutEnoughTime(t *testing.T) {
+	services := startFakeServices(t)
+
+	code, stdout, _ := services.run(t, "gaps", "-timezone", "UTC", "-since", "2024-03-04", "-until", "2024-03-07")
+	if code != exitProblemsFound {
+		t.Errorf("expected exit %d, got %d", exitProblemsFound, code)
+	}
+
+	for _, expected := range []string{
+		"Mon 2024-03-04  short    3.93 h tracked, 8.00 h expected",
+		"Tue 2024-03-05  short    0.50 h tracked, 8.00 h expected",
+		"Wed 2024-03-06  missing  nothing tracked, 8.00 h expected",
+	} {
+		if !strings.Contains(stdout, expected) {
+			t.Errorf("expected %q in:\n%s", expected, stdout)
+		}
+	}
+}
+
+func TestGapsCountEntryCrossingMidnightIntoRange(t *testing.T) {
+	services := startFakeServices(t)
+	proteus := int32(1)
+	services.toggl.AddTimeEntry(fakeapi.TogglTimeEntry{Id: 5, ProjectId: &proteus, Start: time.Date(2024, 3, 5, 22, 0, 0, 0, time.UTC), Stop: time.Date(2024, 3, 6, 6, 0, 0, 0, time.UTC), Description: "Night release"})
+
+	code, stdout, _ := services.run(t, "gaps", "-timezone", "UTC", "-since", "2024-03-06", "-until", "2024-03-07", "-min-hours", "4", "-max-gap", "0")
+	if code != exitOk {
+		t.Errorf("expected the six hours after midnight counted, got exit %d:\n%s", code, stdout)
+	}
+}
+
+func TestDaemonRemindsOfMissingTracking(t *testing.T) {
+	services := startFakeServices(t)
+	smtpServer, err := fakeapi.StartSmtp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { smtpServer.Close() })
+	services.env = append(services.env,
+		"TIMETRACK_NOTIFIERS=smtp",
+		"TIMETRACK_SMTP_ADDRESS="+smtpServer.Addr(),
+		"TIMETRACK_SMTP_FROM=timetrack@example.com",
+		"TIMETRACK_SMTP_TO=jan@example.com",
+	)
+	directory := t.TempDir()
+	statePath := filepath.Join(directory, "state.json")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan int)
+	go func() {
+		code, _, _ := services.runWithContext(ctx, "daemon", "-bearer", "jwt", "-timezone", "UTC", "-interval", "1h",
+			"-lock", filepath.Join(directory, "daemon.lock"), "-state", statePath)
+		done <- code
+	}()
+
+	select {
+	case <-smtpServer.Delivered():
+	case <-time.After(5 * time.Second):
+		t.Error("no reminder was sent")
+	}
+	// the state is written after the delivery finished
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if state, _ := os.ReadFile(statePath); strings.Contains(string(state), `"last_reminder":"2024`) {
+			break
+		}
+	}
+	cancel()
+	if code := <-done; code != exitOk {
+		t.Errorf("expected exit %d, got %d", exitOk, code)
+	}
+
+	messages := smtpServer.Messages()
+	if len(messages) != 1 || !strings.Contains(messages[0].Data, "Subject: Toggl time missing for Tue 2024-03-19") {
+		t.Fatalf("unexpected reminders %+v", messages)
+	}
+	state, _ := os.ReadFile(statePath)
+	if !strings.Contains(string(state), `"last_reminder":"2024-03-20T12:00:00Z"`) {
+		t.Errorf("expected the reminder in the state, got %s", state)
+	}
+}
+
+func TestRunningTimerIsNotSynced(t *testing.T) {
+	services := startFakeServices(t)
+	proteus := int32(1)
+	services.toggl.AddTimeEntry(fakeapi.TogglTimeEntry{Id: 4, ProjectId: &proteus, Start: time.Date(2024, 3, 20, 10, 0, 0, 0, time.UTC), Description: "Running"})
+
+	code, _, stderr := services.run(t, "sync", "-bearer", "jwt", "-timezone", "UTC", "-since", "2024-03-01", "-until", "2024-04-01")
+	if code != exitOk {
+		t.Fatalf("expected exit %d, got %d: %s", exitOk, code, stderr)
+	}
+
+	events := services.sloneek.Events()
+	if len(events) != 2 {
+		t.Errorf("expected the stopped entries only, got %+v", events)
+	}
+}
+
+func TestCredentialsReadPassphraseAndValueFromOnePipe(t *testing.T) {
+	env := []string{"TIMETRACK_CREDENTIALS_FILE=" + filepath.Join(t.TempDir(), "credentials.enc")}
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	code := Run(context.Background(), []string{"credentials", "set", "sloneek_bearer", "-backend", "file"}, env, stdout, stderr, WithStdin(strings.NewReader("passphrase\njwt\n")))
+	if code != exitOk {
+		t.Fatalf("expected exit %d, got %d: %s", exitOk, code, stderr)
+	}
+
+	code = Run(context.Background(), []string{"credentials", "get", "sloneek_bearer", "-backend", "file"}, env, stdout, stderr, WithStdin(strings.NewReader("passphrase\n")))
+	if code != exitOk || strings.TrimSpace(stdout.String()) != "jwt" {
+		t.Errorf("expected the stored value, got %d %q: %s", code, stdout, stderr)
+	}
+}
@@ -0,0 +1,211 @@
+// Package notify delivers short messages to people, e.g. reminders of missing time tracking.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+type Message struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, message Message) error
+}
+
+// ErrPartiallyDelivered is returned by Multi when some notifiers failed but at least one delivered
+// the message, callers should not repeat it through the others.
+var ErrPartiallyDelivered = errors.New("message delivered only by some notifiers")
+
+// smtpTimeout bounds a mail delivery when the context has no deadline.
+const smtpTimeout = time.Minute
+
+// Multi notifies through all notifiers, a failing one does not stop the rest.
+type Multi []Notifier
+
+func (notifiers Multi) Notify(ctx context.Context, message Message) error {
+	errs := []error{}
+	for _, notifier := range notifiers {
+		err := notifier.Notify(ctx, message)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 && len(errs) < len(notifiers) {
+		return fmt.Errorf("%w: %w", ErrPartiallyDelivered, errors.Join(errs...))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Smtp sends a plain text mail. Username and Password are optional, net/smtp only sends
+// them over TLS or to localhost.
+type Smtp struct {
+	Address  string
+	From     string
+	To       []string
+	Username string
+	Password string
+}
+
+func (notifier *Smtp) Notify(ctx context.Context, message Message) error {
+	host, _, err := net.SplitHostPort(notifier.Address)
+	if err != nil {
+		return fmt.Errorf("invalid SMTP address %q: %w", notifier.Address, err)
+	}
+
+	var auth smtp.Auth
+	if notifier.Username != "" {
+		auth = smtp.PlainAuth("", notifier.Username, notifier.Password, host)
+	}
+
+	mail := strings.Builder{}
+	fmt.Fprintf(&mail, "From: %s\r\n", notifier.From)
+	fmt.Fprintf(&mail, "To: %s\r\n", strings.Join(notifier.To, ", "))
+	fmt.Fprintf(&mail, "Subject: %s\r\n", message.Subject)
+	fmt.Fprintf(&mail, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	mail.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n")
+	mail.WriteString(strings.ReplaceAll(message.Body, "\n", "\r\n"))
+	mail.WriteString("\r\n")
+
+	return notifier.send(ctx, host, auth, []byte(mail.String()))
+}
+
+// send is smtp.SendMail over a connection bound to the context, so a stuck server cannot
+// block the daemon.
+func (notifier *Smtp) send(ctx context.Context, host string, auth smtp.Auth, mail []byte) error {
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", notifier.Address)
+	if err != nil {
+		return err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+	conn.SetDeadline(deadline)
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		err = client.StartTLS(&tls.Config{ServerName: host})
+		if err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		err = client.Auth(auth)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = client.Mail(notifier.From)
+	if err != nil {
+		return err
+	}
+	for _, recipient := range notifier.To {
+		err = client.Rcpt(recipient)
+		if err != nil {
+			return err
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(mail)
+	if err != nil {
+		return err
+	}
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+
+	// the server accepted the mail once the data is closed, a failed goodbye changes nothing
+	client.Quit()
+	return nil
+}
+
+// Webhook posts the message as JSON. Text repeats subject and body for chat services like
+// Slack or Mattermost, which only show the text field of incoming webhooks.
+type Webhook struct {
+	Url    string
+	Client *http.Client
+}
+
+func (notifier *Webhook) Notify(ctx context.Context, message Message) error {
+	payload, err := json.Marshal(map[string]string{
+		"subject": message.Subject,
+		"body":    message.Body,
+		"text":    message.Subject + "\n" + message.Body,
+	})
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, notifier.Url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	client := notifier.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", response.StatusCode)
+	}
+
+	return nil
+}
+
+// Desktop shows a desktop notification with notify-send, or another command taking the same
+// summary and body arguments.
+type Desktop struct {
+	Command string
+}
+
+func (notifier *Desktop) Notify(ctx context.Context, message Message) error {
+	command := notifier.Command
+	if command == "" {
+		command = "notify-send"
+	}
+
+	output, err := exec.CommandContext(ctx, command, message.Subject, message.Body).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s failed: %w: %s", command, err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
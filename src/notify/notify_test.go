@@ -0,0 +1,126 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"timetrack-sync/src/fakeapi"
+)
+
+var testMessage = Message{Subject: "Toggl time missing", Body: "Nothing tracked on Tue 2024-03-19."}
+
+func TestSmtpSendsMail(t *testing.T) {
+	server, err := fakeapi.StartSmtp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	notifier := &Smtp{Address: server.Addr(), From: "timetrack@example.com", To: []string{"jan@example.com", "hr@example.com"}}
+	err = notifier.Notify(context.Background(), testMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages := server.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("Expected one mail, got %+v", messages)
+	}
+	if messages[0].From != "timetrack@example.com" || strings.Join(messages[0].To, ",") != "jan@example.com,hr@example.com" {
+		t.Errorf("Unexpected envelope %+v", messages[0])
+	}
+	for _, expected := range []string{"Subject: Toggl time missing", "Nothing tracked on Tue 2024-03-19."} {
+		if !strings.Contains(messages[0].Data, expected) {
+			t.Errorf("Expected %q in:\n%s", expected, messages[0].Data)
+		}
+	}
+}
+
+func TestSmtpStopsWithContext(t *testing.T) {
+	// accepts connections but never greets, like a stuck server
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	notifier := &Smtp{Address: listener.Addr().String(), From: "timetrack@example.com", To: []string{"jan@example.com"}}
+
+	started := time.Now()
+	err = notifier.Notify(ctx, testMessage)
+	if err == nil || time.Since(started) > 5*time.Second {
+		t.Errorf("Expected the delivery to stop with the context, got %v after %v", err, time.Since(started))
+	}
+}
+
+func TestMultiFailsWhenNothingWasDelivered(t *testing.T) {
+	notifier := Multi{&Desktop{Command: "false"}, &Desktop{Command: "false"}}
+
+	err := notifier.Notify(context.Background(), testMessage)
+	if err == nil || errors.Is(err, ErrPartiallyDelivered) {
+		t.Errorf("Expected a plain failure, got %v", err)
+	}
+}
+
+func TestWebhookPostsJson(t *testing.T) {
+	var received map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		json.NewDecoder(request.Body).Decode(&received)
+	}))
+	defer server.Close()
+
+	err := (&Webhook{Url: server.URL}).Notify(context.Background(), testMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if received["subject"] != testMessage.Subject || received["text"] != testMessage.Subject+"\n"+testMessage.Body {
+		t.Errorf("Unexpected payload %v", received)
+	}
+}
+
+func TestWebhookFailsOnErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	err := (&Webhook{Url: server.URL}).Notify(context.Background(), testMessage)
+	if err == nil || !strings.Contains(err.Error(), "502") {
+		t.Errorf("Expected status error, got %v", err)
+	}
+}
+
+func TestMultiNotifiesAllAndJoinsErrors(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		calls++
+	}))
+	defer server.Close()
+
+	notifier := Multi{&Desktop{Command: "false"}, &Webhook{Url: server.URL}}
+	err := notifier.Notify(context.Background(), testMessage)
+	if !errors.Is(err, ErrPartiallyDelivered) || !strings.Contains(err.Error(), "false failed") {
+		t.Errorf("Expected partial delivery with desktop error, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected the webhook to be called after the failure, got %d calls", calls)
+	}
+}
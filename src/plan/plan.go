@@ -0,0 +1,274 @@
+package plan
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+	"timetrack-sync/src/sloneek"
+
+	"github.com/rs/zerolog"
+)
+
+type OperationKind string
+
+const (
+	Create OperationKind = "create"
+	Update OperationKind = "update"
+	Delete OperationKind = "delete"
+)
+
+var ErrStateChanged = errors.New("Sloneek state changed since the plan was created")
+
+// Operation is a single change of Sloneek state. Names are stored next to IDs only for review,
+// apply always uses the IDs.
+type Operation struct {
+	Kind         OperationKind `json:"kind"`
+	SloneekId    string        `json:"sloneek_id,omitempty"`
+	TogglEntryId int64         `json:"toggl_entry_id,omitempty"`
+	ActivityId   string        `json:"activity_id"`
+	Activity     string        `json:"activity"`
+	CategoryId   *string       `json:"category_id,omitempty"`
+	Category     string        `json:"category"`
+	Since        time.Time     `json:"since"`
+	Until        time.Time     `json:"until"`
+	Note         string        `json:"note,omitempty"`
+	AutoApprove  bool          `json:"auto_approve,omitempty"`
+}
+
+func (operation *Operation) TimeEntry() sloneek.TimeEntry {
+	entry := sloneek.TimeEntry{
+		Id:          operation.SloneekId,
+		SourceId:    operation.TogglEntryId,
+		ActivityId:  operation.ActivityId,
+		CategoryId:  operation.CategoryId,
+		Since:       operation.Since,
+		Until:       operation.Until,
+		AutoApprove: operation.AutoApprove,
+	}
+	entry.SetNote(operation.Note)
+
+	return entry
+}
+
+type Plan struct {
+	CreatedAt time.Time `json:"created_at"`
+	Since     time.Time `json:"since"`
+	Until     time.Time `json:"until"`
+	// SloneekFingerprint identifies the Sloneek entries of the range at planning time
+	SloneekFingerprint string      `json:"sloneek_fingerprint"`
+	Operations         []Operation `json:"operations"`
+}
+
+// Executor persists plan operations, implemented by sloneek.SloneekClient.
+type Executor interface {
+	SaveTimeEntry(timeEntry *sloneek.TimeEntry) error
+	UpdateTimeEntry(timeEntry *sloneek.TimeEntry) error
+	DeleteTimeEntry(id string) error
+}
+
+func sameCategory(a *string, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	return *a == *b
+}
+
+// Build diffs entries mapped from Toggl against entries already stored in Sloneek. Mapped entries
+// must contain every part of Toggl entries that starts in the range, even when the Toggl entry
+// started the day before. Identical entries are left alone. Stored entries of the same Toggl
+// entry, or without a known Toggl entry but with the same activity, category and start, are
+// updated and the rest is created. Stored entries of Toggl entries that are gone are deleted when
+// they start in the range. Entries not created by a sync (e.g. vacation entered in Sloneek) and
+// entries starting outside the range, like the first half of an entry crossing midnight, are
+// never deleted.
+func Build(
+	mapped []sloneek.TimeEntry,
+	existing []sloneek.TimeEntry,
+	activities []sloneek.Activity,
+	categories []sloneek.Category,
+	since time.Time,
+	until time.Time,
+) *Plan {
+	plan := &Plan{
+		CreatedAt:          time.Now(),
+		Since:              since,
+		Until:              until,
+		SloneekFingerprint: Fingerprint(existing),
+		Operations:         []Operation{},
+	}
+
+	inRange := func(entry *sloneek.TimeEntry) bool {
+		return !entry.Since.Before(since) && entry.Since.Before(until)
+	}
+	sameDay := func(a time.Time, b time.Time) bool {
+		return a.In(since.Location()).Format(time.DateOnly) == b.In(since.Location()).Format(time.DateOnly)
+	}
+
+	used := make([]bool, len(existing))
+	findExisting := func(matches func(stored *sloneek.TimeEntry) bool) int {
+		for i := range existing {
+			if !used[i] && matches(&existing[i]) {
+				return i
+			}
+		}
+
+		return -1
+	}
+
+	operationFor := func(kind OperationKind, entry *sloneek.TimeEntry) Operation {
+		return Operation{
+			Kind:         kind,
+			SloneekId:    entry.Id,
+			TogglEntryId: entry.SourceId,
+			ActivityId:   entry.ActivityId,
+			Activity:     sloneek.ActivityName(activities, entry.ActivityId),
+			CategoryId:   entry.CategoryId,
+			Category:     sloneek.CategoryName(categories, entry.CategoryId),
+			Since:        entry.Since,
+			Until:        entry.Until,
+			Note:         entry.GetNote(),
+			AutoApprove:  entry.AutoApprove,
+		}
+	}
+
+	pending := []sloneek.TimeEntry{}
+	for _, entry := range mapped {
+		index := findExisting(func(stored *sloneek.TimeEntry) bool {
+			return stored.ActivityId == entry.ActivityId && sameCategory(stored.CategoryId, entry.CategoryId) &&
+				stored.Since.Equal(entry.Since) && stored.Until.Equal(entry.Until)
+		})
+		if index != -1 {
+			used[index] = true
+			continue
+		}
+
+		pending = append(pending, entry)
+	}
+
+	for _, entry := range pending {
+		// parts of one Toggl entry split at midnight are matched day by day
+		index := findExisting(func(stored *sloneek.TimeEntry) bool {
+			return stored.SourceId != 0 && stored.SourceId == entry.SourceId && sameDay(stored.Since, entry.Since)
+		})
+		if index == -1 {
+			index = findExisting(func(stored *sloneek.TimeEntry) bool {
+				return stored.SourceId != 0 && stored.SourceId == entry.SourceId && inRange(stored)
+			})
+		}
+		if index == -1 {
+			index = findExisting(func(stored *sloneek.TimeEntry) bool {
+				return stored.SourceId == 0 && stored.ActivityId == entry.ActivityId && sameCategory(stored.CategoryId, entry.CategoryId) &&
+					stored.Since.Equal(entry.Since)
+			})
+		}
+		if index == -1 {
+			plan.Operations = append(plan.Operations, operationFor(Create, &entry))
+			continue
+		}
+
+		used[index] = true
+		entry.Id = existing[index].Id
+		plan.Operations = append(plan.Operations, operationFor(Update, &entry))
+	}
+
+	for i := range existing {
+		if !used[i] && existing[i].SourceId != 0 && inRange(&existing[i]) {
+			plan.Operations = append(plan.Operations, operationFor(Delete, &existing[i]))
+		}
+	}
+
+	slices.SortStableFunc(plan.Operations, func(a, b Operation) int { return a.Since.Compare(b.Since) })
+	return plan
+}
+
+// SkipDeletes drops delete operations so unattended syncs only create and update entries.
+// It returns the number of dropped operations.
+func (plan *Plan) SkipDeletes() int {
+	count := len(plan.Operations)
+	plan.Operations = slices.DeleteFunc(plan.Operations, func(operation Operation) bool { return operation.Kind == Delete })
+	return count - len(plan.Operations)
+}
+
+// Fingerprint hashes stored entries independently of their order.
+func Fingerprint(entries []sloneek.TimeEntry) string {
+	lines := make([]string, len(entries))
+	for i, entry := range entries {
+		categoryId := ""
+		if entry.CategoryId != nil {
+			categoryId = *entry.CategoryId
+		}
+
+		lines[i] = fmt.Sprintf("%s|%d|%s|%s|%d|%d|%s", entry.Id, entry.SourceId, entry.ActivityId, categoryId, entry.Since.Unix(), entry.Until.Unix(), entry.GetNote())
+	}
+
+	slices.Sort(lines)
+	hash := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(hash[:])
+}
+
+func Write(plan *Plan, path string) error {
+	payload, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, payload, 0o600)
+}
+
+func Read(path string) (*Plan, error) {
+	payload, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var plan Plan
+	err = json.Unmarshal(payload, &plan)
+	if err != nil {
+		return nil, err
+	}
+
+	return &plan, nil
+}
+
+// Apply executes plan operations in order. It refuses to run when the current Sloneek
+// entries of the planned range differ from those the plan was built against.
+func Apply(plan *Plan, current []sloneek.TimeEntry, executor Executor, logger *zerolog.Logger) error {
+	if plan == nil {
+		return errors.New("Plan may not be nil")
+	}
+
+	if Fingerprint(current) != plan.SloneekFingerprint {
+		logger.Error().Msg("Sloneek entries differ from the planned state")
+		return ErrStateChanged
+	}
+
+	for i, operation := range plan.Operations {
+		entry := operation.TimeEntry()
+		var err error
+		switch operation.Kind {
+		case Create:
+			err = executor.SaveTimeEntry(&entry)
+		case Update:
+			err = executor.UpdateTimeEntry(&entry)
+		case Delete:
+			err = executor.DeleteTimeEntry(operation.SloneekId)
+		default:
+			err = fmt.Errorf("Unknown operation kind %q", operation.Kind)
+		}
+
+		if err != nil {
+			logger.Error().Err(err).Int("operation", i).Any("entry", operation).Msg("Failed to apply plan operation")
+			return err
+		}
+	}
+
+	logger.Info().Int("operations", len(plan.Operations)).Msg("Plan applied")
+	return nil
+}
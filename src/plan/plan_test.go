@@ -0,0 +1,178 @@
+package plan
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+	"timetrack-sync/src/sloneek"
+	testutils "timetrack-sync/src/testUtils"
+
+	"github.com/rs/zerolog"
+)
+
+type recordingExecutor struct {
+	saved   []sloneek.TimeEntry
+	updated []sloneek.TimeEntry
+	deleted []string
+}
+
+func (executor *recordingExecutor) SaveTimeEntry(timeEntry *sloneek.TimeEntry) error {
+	executor.saved = append(executor.saved, *timeEntry)
+	return nil
+}
+
+func (executor *recordingExecutor) UpdateTimeEntry(timeEntry *sloneek.TimeEntry) error {
+	executor.updated = append(executor.updated, *timeEntry)
+	return nil
+}
+
+func (executor *recordingExecutor) DeleteTimeEntry(id string) error {
+	executor.deleted = append(executor.deleted, id)
+	return nil
+}
+
+var (
+	testRangeSince = time.Date(2024, 9, 2, 0, 0, 0, 0, time.UTC)
+	testRangeUntil = time.Date(2024, 9, 6, 0, 0, 0, 0, time.UTC)
+)
+
+func testEntries(t *testing.T) ([]sloneek.TimeEntry, []sloneek.TimeEntry) {
+	t.Helper()
+	categoryId := "c1"
+	mapped := []sloneek.TimeEntry{
+		// unchanged
+		{SourceId: 1, ActivityId: "a1", CategoryId: &categoryId, Since: testutils.DateTimeFromString("2024-09-02 08:00:00", t), Until: testutils.DateTimeFromString("2024-09-02 10:00:00", t)},
+		// updated, stopped later
+		{SourceId: 2, ActivityId: "a1", CategoryId: &categoryId, Since: testutils.DateTimeFromString("2024-09-02 11:00:00", t), Until: testutils.DateTimeFromString("2024-09-02 13:00:00", t)},
+		// created
+		{SourceId: 3, ActivityId: "a2", Since: testutils.DateTimeFromString("2024-09-03 09:00:00", t), Until: testutils.DateTimeFromString("2024-09-03 10:00:00", t)},
+	}
+	existing := []sloneek.TimeEntry{
+		{Id: "s1", SourceId: 1, ActivityId: "a1", CategoryId: &categoryId, Since: testutils.DateTimeFromString("2024-09-02 08:00:00", t), Until: testutils.DateTimeFromString("2024-09-02 10:00:00", t)},
+		{Id: "s2", SourceId: 2, ActivityId: "a1", CategoryId: &categoryId, Since: testutils.DateTimeFromString("2024-09-02 11:00:00", t), Until: testutils.DateTimeFromString("2024-09-02 12:00:00", t)},
+		// deleted, the Toggl entry is gone
+		{Id: "s3", SourceId: 4, ActivityId: "a1", CategoryId: &categoryId, Since: testutils.DateTimeFromString("2024-09-04 08:00:00", t), Until: testutils.DateTimeFromString("2024-09-04 09:00:00", t)},
+		// not touched, activity is not synced from Toggl
+		{Id: "s4", ActivityId: "vacation", Since: testutils.DateTimeFromString("2024-09-05 08:00:00", t), Until: testutils.DateTimeFromString("2024-09-05 16:00:00", t)},
+		// not touched, entered in Sloneek by hand
+		{Id: "s5", ActivityId: "a1", Since: testutils.DateTimeFromString("2024-09-05 08:00:00", t), Until: testutils.DateTimeFromString("2024-09-05 09:00:00", t)},
+		// not touched, first half of an entry crossing midnight into the range
+		{Id: "s6", SourceId: 5, ActivityId: "a1", Since: testutils.DateTimeFromString("2024-09-01 23:00:00", t), Until: testutils.DateTimeFromString("2024-09-02 00:00:00", t)},
+	}
+
+	return mapped, existing
+}
+
+func TestBuildCreatesUpdatesAndDeletes(t *testing.T) {
+	mapped, existing := testEntries(t)
+
+	result := Build(mapped, existing, []sloneek.Activity{{Id: "a1", Name: "Vývoj"}}, nil, testRangeSince, testRangeUntil)
+
+	expected := []struct {
+		Kind         OperationKind
+		SloneekId    string
+		TogglEntryId int64
+	}{
+		{Kind: Update, SloneekId: "s2", TogglEntryId: 2},
+		{Kind: Create, SloneekId: "", TogglEntryId: 3},
+		{Kind: Delete, SloneekId: "s3", TogglEntryId: 4},
+	}
+
+	if len(result.Operations) != len(expected) {
+		t.Fatalf("Expected %d operations, got %v", len(expected), result.Operations)
+	}
+	for i, operation := range result.Operations {
+		if operation.Kind != expected[i].Kind || operation.SloneekId != expected[i].SloneekId || operation.TogglEntryId != expected[i].TogglEntryId {
+			t.Errorf("Unexpected operation %d. Expected %v, got %v", i, expected[i], operation)
+		}
+	}
+	if result.Operations[0].Activity != "Vývoj" {
+		t.Errorf("Expected activity name for review, got %q", result.Operations[0].Activity)
+	}
+}
+
+func TestApplyExecutesPlanReadFromFile(t *testing.T) {
+	mapped, existing := testEntries(t)
+	path := filepath.Join(t.TempDir(), "plan.json")
+
+	err := Write(Build(mapped, existing, nil, nil, testRangeSince, testRangeUntil), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// order of stored entries returned by the API must not matter
+	reordered := []sloneek.TimeEntry{existing[5], existing[4], existing[3], existing[2], existing[1], existing[0]}
+	executor := &recordingExecutor{}
+	err = Apply(result, reordered, executor, &zerolog.Logger{})
+	if err != nil {
+		t.Fatalf("Apply returned unexpected error: %v", err)
+	}
+
+	if len(executor.saved) != 1 || executor.saved[0].SourceId != 3 {
+		t.Errorf("Unexpected saved entries %v", executor.saved)
+	}
+	if len(executor.updated) != 1 || executor.updated[0].Id != "s2" || !executor.updated[0].Until.Equal(mapped[1].Until) {
+		t.Errorf("Unexpected updated entries %v", executor.updated)
+	}
+	if len(executor.deleted) != 1 || executor.deleted[0] != "s3" {
+		t.Errorf("Unexpected deleted entries %v", executor.deleted)
+	}
+}
+
+func TestApplyRefusesWhenStateChanged(t *testing.T) {
+	mapped, existing := testEntries(t)
+	result := Build(mapped, existing, nil, nil, testRangeSince, testRangeUntil)
+
+	changed := append([]sloneek.TimeEntry{}, existing...)
+	changed[1].Until = changed[1].Until.Add(15 * time.Minute)
+
+	executor := &recordingExecutor{}
+	err := Apply(result, changed, executor, &zerolog.Logger{})
+	if !errors.Is(err, ErrStateChanged) {
+		t.Errorf("Expected ErrStateChanged, got %v", err)
+	}
+	if len(executor.saved)+len(executor.updated)+len(executor.deleted) != 0 {
+		t.Errorf("Expected no operations to be executed")
+	}
+}
+
+func TestBuildKeepsEntryCrossingMidnightOnPartialSync(t *testing.T) {
+	// 23:00-01:00 was synced as two halves, now only the second day is synced
+	existing := []sloneek.TimeEntry{
+		{Id: "s1", SourceId: 7, ActivityId: "a1", Since: testutils.DateTimeFromString("2024-03-19 23:00:00", t), Until: testutils.DateTimeFromString("2024-03-20 00:00:00", t)},
+		{Id: "s2", SourceId: 7, ActivityId: "a1", Since: testutils.DateTimeFromString("2024-03-20 00:00:00", t), Until: testutils.DateTimeFromString("2024-03-20 01:00:00", t)},
+	}
+	mapped := []sloneek.TimeEntry{
+		{SourceId: 7, ActivityId: "a1", Since: testutils.DateTimeFromString("2024-03-20 00:00:00", t), Until: testutils.DateTimeFromString("2024-03-20 01:30:00", t)},
+	}
+
+	result := Build(mapped, existing, nil, nil, time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 21, 0, 0, 0, 0, time.UTC))
+
+	if len(result.Operations) != 1 || result.Operations[0].Kind != Update || result.Operations[0].SloneekId != "s2" {
+		t.Errorf("Expected only an update of the second half, got %+v", result.Operations)
+	}
+}
+
+func TestSkipDeletesKeepsCreatesAndUpdates(t *testing.T) {
+	mapped, existing := testEntries(t)
+	result := Build(mapped, existing, nil, nil, testRangeSince, testRangeUntil)
+
+	skipped := result.SkipDeletes()
+
+	executor := &recordingExecutor{}
+	err := Apply(result, existing, executor, &zerolog.Logger{})
+	if err != nil {
+		t.Fatalf("Apply returned unexpected error: %v", err)
+	}
+	if skipped != 1 || len(executor.deleted) != 0 {
+		t.Errorf("Expected the delete skipped, skipped %d, deleted %v", skipped, executor.deleted)
+	}
+	if len(executor.saved) != 1 || len(executor.updated) != 1 {
+		t.Errorf("Expected the create and update applied, got %v and %v", executor.saved, executor.updated)
+	}
+}
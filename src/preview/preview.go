@@ -0,0 +1,111 @@
+package preview
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+	"timetrack-sync/src/sloneek"
+	toggltrack "timetrack-sync/src/togglTrack"
+	"timetrack-sync/src/utils"
+)
+
+const (
+	colorReset  = "\033[0m"
+	colorBold   = "\033[1m"
+	colorDim    = "\033[2m"
+	colorYellow = "\033[33m"
+)
+
+// ShouldColor reports whether the file is a terminal and colors are not disabled via NO_COLOR.
+func ShouldColor(file *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+type Printer struct {
+	Activities []sloneek.Activity
+	Categories []sloneek.Category
+	// Originals are Toggl entries before rounding, keyed by their ID
+	Originals map[int64]toggltrack.TimeEntry
+	Location  *time.Location
+	Colored   bool
+}
+
+func (printer *Printer) paint(color string, value string) string {
+	if !printer.Colored {
+		return value
+	}
+
+	return color + value + colorReset
+}
+
+func formatRange(since time.Time, until time.Time, location *time.Location) string {
+	return fmt.Sprintf("%s-%s", since.In(location).Format("15:04"), until.In(location).Format("15:04"))
+}
+
+// Render prints entries that would be sent to Sloneek as a per-day table.
+func (printer *Printer) Render(entries []sloneek.TimeEntry, writer io.Writer) error {
+	sorted := slices.Clone(entries)
+	slices.SortStableFunc(sorted, func(a, b sloneek.TimeEntry) int { return a.Since.Compare(b.Since) })
+
+	tabWriter := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	currentDay := ""
+	dayHours := float64(0)
+	// lines without cells end a tabwriter block, so colored day lines never skew column widths
+	// and the only colored cell value (original times) is the trailing, unaligned one
+	flushDay := func() {
+		if currentDay != "" {
+			fmt.Fprintf(tabWriter, "  %s\n\n", printer.paint(colorDim, fmt.Sprintf("%.2f h", dayHours)))
+		}
+	}
+
+	for _, entry := range sorted {
+		day := utils.StartOfDay(entry.Since, printer.Location)
+		dayLabel := day.Format("Mon 2006-01-02")
+		if dayLabel != currentDay {
+			flushDay()
+			currentDay = dayLabel
+			dayHours = 0
+			fmt.Fprintln(tabWriter, printer.paint(colorBold, dayLabel))
+		}
+
+		rounded := formatRange(entry.Since, entry.Until, printer.Location)
+		original := ""
+		if source, ok := printer.Originals[entry.SourceId]; ok {
+			original = formatRange(source.Start, source.Stop, printer.Location)
+			if original != rounded {
+				original = printer.paint(colorYellow, "was "+original)
+			} else {
+				original = ""
+			}
+		}
+
+		categoryName := ""
+		if entry.CategoryId != nil {
+			categoryName = sloneek.CategoryName(printer.Categories, entry.CategoryId)
+		}
+
+		fmt.Fprintf(tabWriter, "  %s\t%s\t%s\t%s\t%s\n",
+			rounded,
+			sloneek.ActivityName(printer.Activities, entry.ActivityId),
+			categoryName,
+			entry.GetNote(),
+			original,
+		)
+		dayHours += entry.GetHours()
+	}
+
+	flushDay()
+	return tabWriter.Flush()
+}
@@ -0,0 +1,69 @@
+package preview
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"timetrack-sync/src/sloneek"
+	testutils "timetrack-sync/src/testUtils"
+	toggltrack "timetrack-sync/src/togglTrack"
+)
+
+func TestRenderGroupsEntriesByDay(t *testing.T) {
+	categoryId := "c1"
+	printer := &Printer{
+		Activities: []sloneek.Activity{{Id: "a1", Name: "Vývoj"}, {Id: "a2", Name: "Meeting"}},
+		Categories: []sloneek.Category{{Id: categoryId, Name: "Proteus"}},
+		Originals: map[int64]toggltrack.TimeEntry{
+			1: {ID: 1, Start: testutils.DateTimeFromString("2024-09-02 08:03:00", t), Stop: testutils.DateTimeFromString("2024-09-02 09:58:00", t)},
+			2: {ID: 2, Start: testutils.DateTimeFromString("2024-09-03 13:00:00", t), Stop: testutils.DateTimeFromString("2024-09-03 14:00:00", t)},
+		},
+		Location: time.UTC,
+	}
+	entries := []sloneek.TimeEntry{
+		{SourceId: 2, ActivityId: "a2", Since: testutils.DateTimeFromString("2024-09-03 13:00:00", t), Until: testutils.DateTimeFromString("2024-09-03 14:00:00", t)},
+		{SourceId: 1, ActivityId: "a1", CategoryId: &categoryId, Since: testutils.DateTimeFromString("2024-09-02 08:00:00", t), Until: testutils.DateTimeFromString("2024-09-02 10:00:00", t)},
+	}
+
+	buffer := bytes.Buffer{}
+	err := printer.Render(entries, &buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "Mon 2024-09-02\n" +
+		"  08:00-10:00  Vývoj  Proteus    was 08:03-09:58\n" +
+		"  2.00 h\n" +
+		"\n" +
+		"Tue 2024-09-03\n" +
+		"  13:00-14:00  Meeting      \n" +
+		"  1.00 h\n" +
+		"\n"
+	if buffer.String() != expected {
+		t.Errorf("Unexpected output. Expected:\n%q\ngot:\n%q", expected, buffer.String())
+	}
+}
+
+func TestRenderColorsChangedTimes(t *testing.T) {
+	printer := &Printer{
+		Originals: map[int64]toggltrack.TimeEntry{
+			1: {ID: 1, Start: testutils.DateTimeFromString("2024-09-02 08:03:00", t), Stop: testutils.DateTimeFromString("2024-09-02 09:58:00", t)},
+		},
+		Location: time.UTC,
+		Colored:  true,
+	}
+	entries := []sloneek.TimeEntry{
+		{SourceId: 1, ActivityId: "a1", Since: testutils.DateTimeFromString("2024-09-02 08:00:00", t), Until: testutils.DateTimeFromString("2024-09-02 10:00:00", t)},
+	}
+
+	buffer := bytes.Buffer{}
+	err := printer.Render(entries, &buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buffer.String(), colorYellow+"was 08:03-09:58"+colorReset) {
+		t.Errorf("Expected original times to be highlighted, got %q", buffer.String())
+	}
+}
@@ -0,0 +1,163 @@
+package reconcile
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"timetrack-sync/src/sloneek"
+	"timetrack-sync/src/utils"
+)
+
+type DifferenceKind string
+
+const (
+	MissingInSloneek DifferenceKind = "missing_in_sloneek"
+	ExtraInSloneek   DifferenceKind = "extra_in_sloneek"
+	DurationMismatch DifferenceKind = "duration_mismatch"
+)
+
+// hours are compared with a tolerance so float sums do not produce phantom mismatches
+const hoursTolerance = 0.001
+
+type Difference struct {
+	Day          string         `json:"day"`
+	Activity     string         `json:"activity"`
+	Category     string         `json:"category"`
+	Kind         DifferenceKind `json:"kind"`
+	TogglHours   float64        `json:"toggl_hours"`
+	SloneekHours float64        `json:"sloneek_hours"`
+}
+
+type ActivityTotal struct {
+	Activity     string  `json:"activity"`
+	TogglHours   float64 `json:"toggl_hours"`
+	SloneekHours float64 `json:"sloneek_hours"`
+}
+
+type Result struct {
+	Differences []Difference    `json:"differences"`
+	Activities  []ActivityTotal `json:"activities"`
+}
+
+func (result *Result) HasDifferences() bool {
+	return len(result.Differences) > 0
+}
+
+type groupKey struct {
+	day      string
+	activity string
+	category string
+}
+
+type hours struct {
+	toggl   float64
+	sloneek float64
+}
+
+// Compare groups entries mapped from Toggl and entries stored in Sloneek by local day,
+// activity and category and reports every group where the hours do not match.
+func Compare(
+	togglEntries []sloneek.TimeEntry,
+	sloneekEntries []sloneek.TimeEntry,
+	activities []sloneek.Activity,
+	categories []sloneek.Category,
+	location *time.Location,
+) *Result {
+	groups := make(map[groupKey]*hours)
+	activityHours := make(map[string]*hours)
+
+	add := func(entry *sloneek.TimeEntry, isToggl bool) {
+		key := groupKey{
+			day:      utils.StartOfDay(entry.Since, location).Format(time.DateOnly),
+			activity: sloneek.ActivityName(activities, entry.ActivityId),
+			category: sloneek.CategoryName(categories, entry.CategoryId),
+		}
+
+		if groups[key] == nil {
+			groups[key] = &hours{}
+		}
+		if activityHours[key.activity] == nil {
+			activityHours[key.activity] = &hours{}
+		}
+
+		if isToggl {
+			groups[key].toggl += entry.GetHours()
+			activityHours[key.activity].toggl += entry.GetHours()
+		} else {
+			groups[key].sloneek += entry.GetHours()
+			activityHours[key.activity].sloneek += entry.GetHours()
+		}
+	}
+
+	for i := range togglEntries {
+		add(&togglEntries[i], true)
+	}
+	for i := range sloneekEntries {
+		add(&sloneekEntries[i], false)
+	}
+
+	result := &Result{Differences: []Difference{}, Activities: []ActivityTotal{}}
+	for key, value := range groups {
+		if math.Abs(value.toggl-value.sloneek) < hoursTolerance {
+			continue
+		}
+
+		kind := DurationMismatch
+		if value.sloneek == 0 {
+			kind = MissingInSloneek
+		} else if value.toggl == 0 {
+			kind = ExtraInSloneek
+		}
+
+		result.Differences = append(result.Differences, Difference{
+			Day:          key.day,
+			Activity:     key.activity,
+			Category:     key.category,
+			Kind:         kind,
+			TogglHours:   value.toggl,
+			SloneekHours: value.sloneek,
+		})
+	}
+
+	slices.SortFunc(result.Differences, func(a, b Difference) int {
+		if a.Day != b.Day {
+			return strings.Compare(a.Day, b.Day)
+		}
+		if a.Activity != b.Activity {
+			return strings.Compare(a.Activity, b.Activity)
+		}
+		return strings.Compare(a.Category, b.Category)
+	})
+
+	for activity, value := range activityHours {
+		result.Activities = append(result.Activities, ActivityTotal{Activity: activity, TogglHours: value.toggl, SloneekHours: value.sloneek})
+	}
+	slices.SortFunc(result.Activities, func(a, b ActivityTotal) int { return strings.Compare(a.Activity, b.Activity) })
+
+	return result
+}
+
+func Render(result *Result, writer io.Writer) error {
+	tabWriter := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	if !result.HasDifferences() {
+		fmt.Fprintln(tabWriter, "Toggl and Sloneek match.")
+	} else {
+		fmt.Fprintln(tabWriter, "Day\tActivity\tCategory\tDifference\tToggl\tSloneek\t")
+		for _, difference := range result.Differences {
+			fmt.Fprintf(tabWriter, "%s\t%s\t%s\t%s\t%.2f\t%.2f\t\n",
+				difference.Day, difference.Activity, difference.Category, difference.Kind, difference.TogglHours, difference.SloneekHours)
+		}
+	}
+
+	fmt.Fprintln(tabWriter, "\t\t\t")
+	fmt.Fprintln(tabWriter, "Activity\tToggl\tSloneek\t")
+	for _, total := range result.Activities {
+		fmt.Fprintf(tabWriter, "%s\t%.2f\t%.2f\t\n", total.Activity, total.TogglHours, total.SloneekHours)
+	}
+
+	return tabWriter.Flush()
+}
@@ -0,0 +1,75 @@
+package reconcile
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"timetrack-sync/src/sloneek"
+	testutils "timetrack-sync/src/testUtils"
+)
+
+func TestCompareReportsDifferences(t *testing.T) {
+	categoryId := "c1"
+	activities := []sloneek.Activity{{Id: "a1", Name: "Vývoj"}, {Id: "a2", Name: "Meeting"}, {Id: "a3", Name: "Hiring"}}
+	categories := []sloneek.Category{{Id: categoryId, Name: "Proteus"}}
+
+	togglEntries := []sloneek.TimeEntry{
+		// matches
+		{ActivityId: "a1", CategoryId: &categoryId, Since: testutils.DateTimeFromString("2024-09-02 08:00:00", t), Until: testutils.DateTimeFromString("2024-09-02 12:00:00", t)},
+		// missing in Sloneek
+		{ActivityId: "a2", Since: testutils.DateTimeFromString("2024-09-02 13:00:00", t), Until: testutils.DateTimeFromString("2024-09-02 14:00:00", t)},
+		// duration mismatch
+		{ActivityId: "a1", CategoryId: &categoryId, Since: testutils.DateTimeFromString("2024-09-03 08:00:00", t), Until: testutils.DateTimeFromString("2024-09-03 10:00:00", t)},
+	}
+	sloneekEntries := []sloneek.TimeEntry{
+		{Id: "s1", ActivityId: "a1", CategoryId: &categoryId, Since: testutils.DateTimeFromString("2024-09-02 08:00:00", t), Until: testutils.DateTimeFromString("2024-09-02 12:00:00", t)},
+		{Id: "s2", ActivityId: "a1", CategoryId: &categoryId, Since: testutils.DateTimeFromString("2024-09-03 08:00:00", t), Until: testutils.DateTimeFromString("2024-09-03 11:00:00", t)},
+		// extra in Sloneek
+		{Id: "s3", ActivityId: "a3", Since: testutils.DateTimeFromString("2024-09-04 08:00:00", t), Until: testutils.DateTimeFromString("2024-09-04 09:00:00", t)},
+	}
+
+	result := Compare(togglEntries, sloneekEntries, activities, categories, time.UTC)
+
+	expected := []Difference{
+		{Day: "2024-09-02", Activity: "Meeting", Category: "Uncategorized", Kind: MissingInSloneek, TogglHours: 1, SloneekHours: 0},
+		{Day: "2024-09-03", Activity: "Vývoj", Category: "Proteus", Kind: DurationMismatch, TogglHours: 2, SloneekHours: 3},
+		{Day: "2024-09-04", Activity: "Hiring", Category: "Uncategorized", Kind: ExtraInSloneek, TogglHours: 0, SloneekHours: 1},
+	}
+
+	if len(result.Differences) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, result.Differences)
+	}
+	for i := range expected {
+		if result.Differences[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], result.Differences[i])
+		}
+	}
+
+	expectedActivities := []ActivityTotal{{"Hiring", 0, 1}, {"Meeting", 1, 0}, {"Vývoj", 6, 7}}
+	for i := range expectedActivities {
+		if result.Activities[i] != expectedActivities[i] {
+			t.Errorf("Expected %v, got %v", expectedActivities[i], result.Activities[i])
+		}
+	}
+}
+
+func TestCompareMatchingEntries(t *testing.T) {
+	entries := []sloneek.TimeEntry{
+		{ActivityId: "a1", Since: testutils.DateTimeFromString("2024-09-02 08:00:00", t), Until: testutils.DateTimeFromString("2024-09-02 12:00:00", t)},
+	}
+
+	result := Compare(entries, entries, nil, nil, time.UTC)
+	if result.HasDifferences() {
+		t.Errorf("Expected no differences, got %v", result.Differences)
+	}
+
+	buffer := bytes.Buffer{}
+	err := Render(result, &buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buffer.String(), "Toggl and Sloneek match.") {
+		t.Errorf("Unexpected output:\n%s", buffer.String())
+	}
+}
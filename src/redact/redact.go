@@ -0,0 +1,124 @@
+package redact
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Mask replaces every redacted value.
+const Mask = "[REDACTED]"
+
+// shorter values would mask unrelated text, such as digits of timestamps
+const minSecretLength = 4
+
+// DefaultFields are names of log fields and JSON keys whose values are always masked.
+var DefaultFields = []string{
+	"api_key",
+	"api_token",
+	"apikey",
+	"token",
+	"bearer",
+	"bearer_token",
+	"authorization",
+	"password",
+	"passphrase",
+	"secret",
+	"cookie",
+	"set-cookie",
+}
+
+// authorizationValue matches credentials after an Authorization header or field name, also in
+// dumped JSON and header maps, so prose like "basic setup" stays readable.
+var authorizationValue = regexp.MustCompile(`(?i)(\bauthorization\\?"?\s*[:=]\s*\[?\\?"?\s*(?:bearer|basic)\s+)[A-Za-z0-9._~+/=-]+`)
+
+// Writer masks secrets in log lines before they reach the underlying writer. It sits in front
+// of the zerolog output, so it sees every event as a JSON line regardless of the final format.
+type Writer struct {
+	out     io.Writer
+	mutex   sync.RWMutex
+	secrets [][]byte
+	fields  []*regexp.Regexp
+}
+
+// CreateWriter masks values of the given field names, DefaultFields are used when none are given.
+func CreateWriter(out io.Writer, fields ...string) *Writer {
+	if len(fields) == 0 {
+		fields = DefaultFields
+	}
+
+	writer := &Writer{out: out}
+	writer.AddFields(fields...)
+	return writer
+}
+
+// AddFields masks values of further field names, matched case-insensitively.
+func (writer *Writer) AddFields(fields ...string) {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		name := regexp.QuoteMeta(field)
+		// plain JSON of the log event and JSON embedded in a string field, e.g. a dumped body
+		writer.fields = append(writer.fields,
+			regexp.MustCompile(`(?i)("`+name+`"\s*:\s*")(?:[^"\\]|\\.)*(")`),
+			regexp.MustCompile(`(?i)(\\"`+name+`\\"\s*:\s*\\")(?:[^"\\]|\\[^"])*(\\")`),
+		)
+	}
+}
+
+// AddSecret masks the given values wherever they appear. Empty and very short values are ignored.
+func (writer *Writer) AddSecret(values ...string) {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	for _, value := range values {
+		if len(value) < minSecretLength {
+			continue
+		}
+
+		secret := []byte(value)
+		known := false
+		for _, existing := range writer.secrets {
+			if bytes.Equal(existing, secret) {
+				known = true
+				break
+			}
+		}
+		if !known {
+			writer.secrets = append(writer.secrets, secret)
+		}
+	}
+}
+
+// Redact returns the line with all secrets masked.
+func (writer *Writer) Redact(line []byte) []byte {
+	writer.mutex.RLock()
+	defer writer.mutex.RUnlock()
+
+	for _, secret := range writer.secrets {
+		line = bytes.ReplaceAll(line, secret, []byte(Mask))
+	}
+	for _, field := range writer.fields {
+		line = field.ReplaceAll(line, []byte("${1}"+Mask+"${2}"))
+	}
+
+	return authorizationValue.ReplaceAll(line, []byte("${1}"+Mask))
+}
+
+// Write reports the length of the original line, callers do not care about the masking.
+func (writer *Writer) Write(line []byte) (int, error) {
+	_, err := writer.out.Write(writer.Redact(line))
+	if err != nil {
+		return 0, err
+	}
+
+	return len(line), nil
+}
@@ -0,0 +1,123 @@
+package redact
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func logTo(fields ...string) (*zerolog.Logger, *Writer, *bytes.Buffer) {
+	output := &bytes.Buffer{}
+	writer := CreateWriter(output, fields...)
+	logger := zerolog.New(writer).Level(zerolog.DebugLevel)
+	return &logger, writer, output
+}
+
+func TestRedactKnownSecretInAnyField(t *testing.T) {
+	logger, writer, output := logTo()
+	writer.AddSecret("toggl-secret-key")
+
+	logger.Error().Str("key", "toggl-secret-key").Msg("Authentication failed with toggl-secret-key")
+
+	if strings.Contains(output.String(), "toggl-secret-key") {
+		t.Fatalf("secret leaked: %s", output.String())
+	}
+	if strings.Count(output.String(), Mask) != 2 {
+		t.Errorf("expected two masks, got %s", output.String())
+	}
+}
+
+func TestRedactSensitiveFields(t *testing.T) {
+	logger, _, output := logTo()
+
+	logger.Error().Str("api_key", "abcdef").Str("Authorization", "xyz123").Str("status", "401").Msg("")
+
+	for _, leaked := range []string{"abcdef", "xyz123"} {
+		if strings.Contains(output.String(), leaked) {
+			t.Errorf("%s leaked: %s", leaked, output.String())
+		}
+	}
+	if !strings.Contains(output.String(), `"status":"401"`) {
+		t.Errorf("unrelated field masked: %s", output.String())
+	}
+}
+
+func TestRedactDumpedBody(t *testing.T) {
+	logger, _, output := logTo()
+
+	body := `{"id":1,"api_token":"0123456789abcdef","fullname":"Jane"}`
+	logger.Debug().Str("response_body", body).Msg("Response body")
+
+	if strings.Contains(output.String(), "0123456789abcdef") {
+		t.Fatalf("token in body leaked: %s", output.String())
+	}
+	if !strings.Contains(output.String(), "Jane") {
+		t.Errorf("rest of the body should stay readable: %s", output.String())
+	}
+}
+
+func TestRedactAuthorizationHeaderValue(t *testing.T) {
+	logger, _, output := logTo()
+
+	logger.Info().Str("request", "GET /v2/activities Authorization: Bearer eyJhbGciOi.abc-def").Msg("")
+	logger.Info().Msg("headers map[Authorization:[Basic dXNlcjpwYXNz]] sent")
+	logger.Info().Str("body", `{"Authorization": "Bearer c2VjcmV0LWp3dA"}`).Msg("")
+
+	for _, leaked := range []string{"eyJhbGciOi", "dXNlcjpwYXNz", "c2VjcmV0LWp3dA"} {
+		if strings.Contains(output.String(), leaked) {
+			t.Errorf("%s leaked: %s", leaked, output.String())
+		}
+	}
+}
+
+func TestProseAboutAuthenticationIsKept(t *testing.T) {
+	logger, _, output := logTo()
+
+	logger.Info().Msg("Finished basic setup, bearer tokens are read from the keyring")
+
+	if !strings.Contains(output.String(), "basic setup, bearer tokens are read") {
+		t.Errorf("prose was masked: %s", output.String())
+	}
+}
+
+func TestRedactConfiguredFields(t *testing.T) {
+	logger, _, output := logTo("user_uuid")
+
+	logger.Info().Str("user_uuid", "5f1c-42").Str("api_key", "abcdef").Msg("")
+
+	if strings.Contains(output.String(), "5f1c-42") {
+		t.Errorf("configured field leaked: %s", output.String())
+	}
+	// configured fields replace the defaults
+	if !strings.Contains(output.String(), "abcdef") {
+		t.Errorf("expected only configured fields masked: %s", output.String())
+	}
+}
+
+func TestRedactThroughConsoleWriter(t *testing.T) {
+	output := &bytes.Buffer{}
+	writer := CreateWriter(zerolog.ConsoleWriter{Out: output, NoColor: true})
+	writer.AddSecret("bearer-value-42")
+	logger := zerolog.New(writer)
+
+	logger.Error().Str("token", "tok-1234").Str("note", "sent bearer-value-42").Msg("Request")
+
+	for _, leaked := range []string{"tok-1234", "bearer-value-42"} {
+		if strings.Contains(output.String(), leaked) {
+			t.Errorf("%s leaked: %s", leaked, output.String())
+		}
+	}
+}
+
+func TestShortSecretsIgnored(t *testing.T) {
+	logger, writer, output := logTo()
+	writer.AddSecret("", "12")
+
+	logger.Info().Int("count", 12).Msg("")
+
+	if !strings.Contains(output.String(), `"count":12`) {
+		t.Errorf("short secret should not be masked: %s", output.String())
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"timetrack-sync/src/gaps"
+	"timetrack-sync/src/notify"
+
+	"github.com/rs/zerolog"
+)
+
+// notifier combines the comma separated notifiers of TIMETRACK_NOTIFIERS (smtp, webhook or
+// desktop) configured by their own variables, it is nil when none is enabled.
+func (app *app) notifier() (notify.Notifier, error) {
+	notifiers := notify.Multi{}
+	for _, name := range strings.Split(app.getenv("TIMETRACK_NOTIFIERS"), ",") {
+		switch strings.TrimSpace(name) {
+		case "":
+		case "smtp":
+			smtpNotifier := &notify.Smtp{
+				Address:  app.getenv("TIMETRACK_SMTP_ADDRESS"),
+				From:     app.getenv("TIMETRACK_SMTP_FROM"),
+				Username: app.getenv("TIMETRACK_SMTP_USERNAME"),
+				Password: app.getenv("TIMETRACK_SMTP_PASSWORD"),
+			}
+			for _, recipient := range strings.Split(app.getenv("TIMETRACK_SMTP_TO"), ",") {
+				if recipient = strings.TrimSpace(recipient); recipient != "" {
+					smtpNotifier.To = append(smtpNotifier.To, recipient)
+				}
+			}
+			if smtpNotifier.Address == "" || smtpNotifier.From == "" || len(smtpNotifier.To) == 0 {
+				return nil, fmt.Errorf("smtp notifier needs TIMETRACK_SMTP_ADDRESS, TIMETRACK_SMTP_FROM and TIMETRACK_SMTP_TO")
+			}
+
+			app.addSecrets(smtpNotifier.Password)
+			notifiers = append(notifiers, smtpNotifier)
+		case "webhook":
+			url := app.getenv("TIMETRACK_NOTIFY_WEBHOOK_URL")
+			if url == "" {
+				return nil, fmt.Errorf("webhook notifier needs TIMETRACK_NOTIFY_WEBHOOK_URL")
+			}
+
+			notifiers = append(notifiers, &notify.Webhook{Url: url, Client: app.httpClient})
+		case "desktop":
+			notifiers = append(notifiers, &notify.Desktop{Command: app.getenv("TIMETRACK_NOTIFY_COMMAND")})
+		default:
+			return nil, fmt.Errorf("unknown notifier %q, expected smtp, webhook or desktop", name)
+		}
+	}
+
+	if len(notifiers) == 0 {
+		return nil, nil
+	}
+
+	return notifiers, nil
+}
+
+// remindMissingTime notifies when the last workday before now has less Toggl time than the
+// contract expects.
+func remindMissingTime(ctx context.Context, app *app, account *account, notifier notify.Notifier, location *time.Location, now time.Time, logger *zerolog.Logger) error {
+	workContract, err := app.contract()
+	if err != nil {
+		return err
+	}
+	calendar, err := app.holidays()
+	if err != nil {
+		return err
+	}
+
+	local := now.In(location)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+	workday, ok := workContract.PreviousWorkday(today, calendar)
+	if !ok {
+		return nil
+	}
+
+	// an entry started the day before may cross midnight into the workday
+	entries, err := app.togglClient(account, logger).GetTimeEntries(workday.AddDate(0, 0, -1), workday.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+
+	found := gaps.Find(entries, workContract, calendar, gaps.Settings{}, workday, workday.AddDate(0, 0, 1), now, location)
+	if len(found) == 0 {
+		logger.Info().Str("day", workday.Format(time.DateOnly)).Msg("Previous workday is fully tracked")
+		return nil
+	}
+
+	logger.Info().Str("day", workday.Format(time.DateOnly)).Msg("Sending reminder of missing tracking")
+	return notifier.Notify(ctx, notify.Message{
+		Subject: fmt.Sprintf("Toggl time missing for %s", workday.Format("Mon 2006-01-02")),
+		Body:    fmt.Sprintf("%s.\n\nTrack the missing time in Toggl, it is synced to Sloneek with the next run.", found[0].Message()),
+	})
+}
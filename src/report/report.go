@@ -0,0 +1,261 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"timetrack-sync/src/sloneek"
+	"timetrack-sync/src/utils"
+)
+
+type Format string
+
+const (
+	FormatText     Format = "text"
+	FormatCsv      Format = "csv"
+	FormatJson     Format = "json"
+	FormatMarkdown Format = "markdown"
+)
+
+func ParseFormat(value string) (Format, error) {
+	switch Format(strings.ToLower(value)) {
+	case FormatText:
+		return FormatText, nil
+	case FormatCsv:
+		return FormatCsv, nil
+	case FormatJson:
+		return FormatJson, nil
+	case FormatMarkdown, "md":
+		return FormatMarkdown, nil
+	}
+
+	return "", fmt.Errorf("Unknown report format %q", value)
+}
+
+type Total struct {
+	Name  string  `json:"name"`
+	Hours float64 `json:"hours"`
+}
+
+type Report struct {
+	TotalHours float64 `json:"total_hours"`
+	Activities []Total `json:"activities"`
+	Categories []Total `json:"categories"`
+	Days       []Total `json:"days"`
+	Weeks      []Total `json:"weeks"`
+}
+
+type section struct {
+	title  string
+	totals []Total
+}
+
+func (report *Report) sections() []section {
+	return []section{
+		{title: "Activity", totals: report.Activities},
+		{title: "Category", totals: report.Categories},
+		{title: "Day", totals: report.Days},
+		{title: "Week", totals: report.Weeks},
+	}
+}
+
+// Build sums up entry hours per activity, category, local day and ISO week.
+// Activities and categories are sorted by name, days and weeks chronologically.
+func Build(
+	entries []sloneek.TimeEntry,
+	activities []sloneek.Activity,
+	categories []sloneek.Category,
+	location *time.Location,
+) *Report {
+	activityHours := make(map[string]float64)
+	categoryHours := make(map[string]float64)
+	dayHours := make(map[string]float64)
+	weekHours := make(map[string]float64)
+	report := &Report{}
+
+	for _, entry := range entries {
+		hours := entry.GetHours()
+		report.TotalHours += hours
+
+		activityHours[sloneek.ActivityName(activities, entry.ActivityId)] += hours
+		categoryHours[sloneek.CategoryName(categories, entry.CategoryId)] += hours
+
+		day := utils.StartOfDay(entry.Since, location)
+		dayHours[day.Format(time.DateOnly)] += hours
+
+		year, week := day.ISOWeek()
+		weekHours[fmt.Sprintf("%d-W%02d", year, week)] += hours
+	}
+
+	report.Activities = sortedTotals(activityHours)
+	report.Categories = sortedTotals(categoryHours)
+	// dates and ISO weeks sort chronologically as strings
+	report.Days = sortedTotals(dayHours)
+	report.Weeks = sortedTotals(weekHours)
+	return report
+}
+
+func sortedTotals(hours map[string]float64) []Total {
+	totals := make([]Total, 0, len(hours))
+	for name, value := range hours {
+		totals = append(totals, Total{Name: name, Hours: value})
+	}
+
+	slices.SortFunc(totals, func(a, b Total) int { return strings.Compare(a.Name, b.Name) })
+	return totals
+}
+
+func formatHours(hours float64) string {
+	return fmt.Sprintf("%.2f", hours)
+}
+
+func Render(report *Report, format Format, writer io.Writer) error {
+	if report == nil {
+		return errors.New("Report may not be nil")
+	}
+
+	if format == FormatJson {
+		return renderJson(report, writer)
+	}
+
+	return renderSections(report.sections(), report.TotalHours, format, writer)
+}
+
+func renderSections(sections []section, totalHours float64, format Format, writer io.Writer) error {
+	switch format {
+	case FormatText:
+		return renderText(sections, totalHours, writer)
+	case FormatCsv:
+		return renderCsv(sections, totalHours, writer)
+	case FormatMarkdown:
+		return renderMarkdown(sections, totalHours, writer)
+	}
+
+	return fmt.Errorf("Unknown report format %q", format)
+}
+
+func renderText(sections []section, totalHours float64, writer io.Writer) error {
+	tabWriter := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	for _, section := range sections {
+		fmt.Fprintf(tabWriter, "%s\tHours\t\n", section.title)
+		for _, total := range section.totals {
+			fmt.Fprintf(tabWriter, "%s\t%s\t\n", total.Name, formatHours(total.Hours))
+		}
+		fmt.Fprintln(tabWriter, "\t\t")
+	}
+
+	fmt.Fprintf(tabWriter, "Total\t%s\t\n", formatHours(totalHours))
+	return tabWriter.Flush()
+}
+
+func renderCsv(sections []section, totalHours float64, writer io.Writer) error {
+	csvWriter := csv.NewWriter(writer)
+	records := [][]string{{"section", "name", "hours"}}
+	for _, section := range sections {
+		for _, total := range section.totals {
+			records = append(records, []string{strings.ToLower(section.title), total.Name, formatHours(total.Hours)})
+		}
+	}
+
+	records = append(records, []string{"total", "", formatHours(totalHours)})
+	return csvWriter.WriteAll(records)
+}
+
+func renderJson(report *Report, writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+func renderMarkdown(sections []section, totalHours float64, writer io.Writer) error {
+	builder := strings.Builder{}
+	for _, section := range sections {
+		fmt.Fprintf(&builder, "| %s | Hours |\n", section.title)
+		builder.WriteString("| --- | ---: |\n")
+		for _, total := range section.totals {
+			fmt.Fprintf(&builder, "| %s | %s |\n", strings.ReplaceAll(total.Name, "|", "\\|"), formatHours(total.Hours))
+		}
+		builder.WriteString("\n")
+	}
+
+	fmt.Fprintf(&builder, "**Total: %s hours**\n", formatHours(totalHours))
+	_, err := io.WriteString(writer, builder.String())
+	return err
+}
+
+// UserReport is the outcome of a sync for one team member. Error is set when the sync failed,
+// Report may still be present when only saving failed.
+type UserReport struct {
+	Name   string  `json:"name"`
+	Error  string  `json:"error,omitempty"`
+	Report *Report `json:"report,omitempty"`
+}
+
+// RenderTeam renders reports of all users followed by an overview of their totals.
+func RenderTeam(reports []UserReport, format Format, writer io.Writer) error {
+	if format == FormatJson {
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(reports)
+	}
+	if format == FormatCsv {
+		return renderTeamCsv(reports, writer)
+	}
+
+	headingFormat := "== %s ==\n\n"
+	if format == FormatMarkdown {
+		headingFormat = "## %s\n\n"
+	}
+
+	userTotals := []Total{}
+	teamHours := float64(0)
+	for _, userReport := range reports {
+		fmt.Fprintf(writer, headingFormat, userReport.Name)
+		if userReport.Error != "" {
+			fmt.Fprintf(writer, "Sync failed: %s\n\n", userReport.Error)
+		}
+		if userReport.Report == nil {
+			continue
+		}
+
+		err := Render(userReport.Report, format, writer)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(writer)
+
+		userTotals = append(userTotals, Total{Name: userReport.Name, Hours: userReport.Report.TotalHours})
+		teamHours += userReport.Report.TotalHours
+	}
+
+	fmt.Fprintf(writer, headingFormat, "Team")
+	return renderSections([]section{{title: "User", totals: userTotals}}, teamHours, format, writer)
+}
+
+// renderTeamCsv prefixes every row with the user, since CSV has no room for headings.
+func renderTeamCsv(reports []UserReport, writer io.Writer) error {
+	csvWriter := csv.NewWriter(writer)
+	csvWriter.Write([]string{"user", "section", "name", "hours", "error"})
+	for _, userReport := range reports {
+		if userReport.Report == nil {
+			csvWriter.Write([]string{userReport.Name, "total", "", "", userReport.Error})
+			continue
+		}
+
+		for _, section := range userReport.Report.sections() {
+			for _, total := range section.totals {
+				csvWriter.Write([]string{userReport.Name, strings.ToLower(section.title), total.Name, formatHours(total.Hours), ""})
+			}
+		}
+		csvWriter.Write([]string{userReport.Name, "total", "", formatHours(userReport.Report.TotalHours), userReport.Error})
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
@@ -0,0 +1,139 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+	"timetrack-sync/src/sloneek"
+	testutils "timetrack-sync/src/testUtils"
+)
+
+func buildTestReport(t *testing.T) *Report {
+	t.Helper()
+	categoryId := "c1"
+	activities := []sloneek.Activity{{Id: "a1", Name: "Vývoj"}, {Id: "a2", Name: "Meeting"}}
+	categories := []sloneek.Category{{Id: categoryId, Name: "Proteus"}}
+	entries := []sloneek.TimeEntry{
+		{ActivityId: "a1", CategoryId: &categoryId, Since: testutils.DateTimeFromString("2024-09-02 08:00:00", t), Until: testutils.DateTimeFromString("2024-09-02 12:00:00", t)},
+		{ActivityId: "a2", Since: testutils.DateTimeFromString("2024-09-02 13:00:00", t), Until: testutils.DateTimeFromString("2024-09-02 14:30:00", t)},
+		{ActivityId: "a1", CategoryId: &categoryId, Since: testutils.DateTimeFromString("2024-09-09 08:00:00", t), Until: testutils.DateTimeFromString("2024-09-09 10:00:00", t)},
+	}
+
+	return Build(entries, activities, categories, time.UTC)
+}
+
+func TestBuildSumsHoursPerGroup(t *testing.T) {
+	report := buildTestReport(t)
+
+	if report.TotalHours != 7.5 {
+		t.Errorf("Unexpected total hours. Expected 7.5, got %f", report.TotalHours)
+	}
+
+	testCases := []struct {
+		Name     string
+		Totals   []Total
+		Expected []Total
+	}{
+		{Name: "activities", Totals: report.Activities, Expected: []Total{{"Meeting", 1.5}, {"Vývoj", 6}}},
+		{Name: "categories", Totals: report.Categories, Expected: []Total{{"Proteus", 6}, {"Uncategorized", 1.5}}},
+		{Name: "days", Totals: report.Days, Expected: []Total{{"2024-09-02", 5.5}, {"2024-09-09", 2}}},
+		{Name: "weeks", Totals: report.Weeks, Expected: []Total{{"2024-W36", 5.5}, {"2024-W37", 2}}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			if len(testCase.Totals) != len(testCase.Expected) {
+				t.Fatalf("Expected %v, got %v", testCase.Expected, testCase.Totals)
+			}
+			for i := range testCase.Expected {
+				if testCase.Totals[i] != testCase.Expected[i] {
+					t.Errorf("Expected %v, got %v", testCase.Expected[i], testCase.Totals[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRenderFormats(t *testing.T) {
+	report := buildTestReport(t)
+
+	testCases := []struct {
+		Format   Format
+		Contains []string
+	}{
+		{Format: FormatText, Contains: []string{"Activity  ", "Vývoj", "6.00", "Total", "7.50"}},
+		{Format: FormatCsv, Contains: []string{"section,name,hours\n", "activity,Vývoj,6.00\n", "week,2024-W36,5.50\n", "total,,7.50\n"}},
+		{Format: FormatMarkdown, Contains: []string{"| Day | Hours |\n", "| --- | ---: |\n", "| 2024-09-09 | 2.00 |\n", "**Total: 7.50 hours**"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(string(testCase.Format), func(t *testing.T) {
+			buffer := bytes.Buffer{}
+			err := Render(report, testCase.Format, &buffer)
+			if err != nil {
+				t.Fatalf("Render returned unexpected error: %v", err)
+			}
+
+			for _, expected := range testCase.Contains {
+				if !strings.Contains(buffer.String(), expected) {
+					t.Errorf("Expected output to contain %q, got:\n%s", expected, buffer.String())
+				}
+			}
+		})
+	}
+}
+
+func TestRenderJsonRoundTrips(t *testing.T) {
+	report := buildTestReport(t)
+	buffer := bytes.Buffer{}
+	err := Render(report, FormatJson, &buffer)
+	if err != nil {
+		t.Fatalf("Render returned unexpected error: %v", err)
+	}
+
+	var decoded Report
+	err = json.Unmarshal(buffer.Bytes(), &decoded)
+	if err != nil {
+		t.Fatalf("Output is not valid JSON: %v", err)
+	}
+	if decoded.TotalHours != report.TotalHours || len(decoded.Days) != len(report.Days) {
+		t.Errorf("Unexpected decoded report %v", decoded)
+	}
+}
+
+func TestParseFormatRejectsUnknownFormat(t *testing.T) {
+	_, err := ParseFormat("xml")
+	if err == nil {
+		t.Errorf("Expected to fail but did not fail")
+	}
+}
+
+func TestRenderTeamIncludesFailedUsersAndOverview(t *testing.T) {
+	reports := []UserReport{
+		{Name: "Alice", Report: buildTestReport(t)},
+		{Name: "Bob", Error: "Toggl authentication failed"},
+	}
+
+	buffer := bytes.Buffer{}
+	err := RenderTeam(reports, FormatMarkdown, &buffer)
+	if err != nil {
+		t.Fatalf("RenderTeam returned unexpected error: %v", err)
+	}
+
+	for _, expected := range []string{"## Alice\n", "## Bob\n\nSync failed: Toggl authentication failed", "## Team\n", "| User | Hours |\n", "| Alice | 7.50 |\n"} {
+		if !strings.Contains(buffer.String(), expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, buffer.String())
+		}
+	}
+
+	buffer.Reset()
+	err = RenderTeam(reports, FormatCsv, &buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buffer.String(), "Alice,activity,Vývoj,6.00,\n") || !strings.Contains(buffer.String(), "Bob,total,,,Toggl authentication failed\n") {
+		t.Errorf("Unexpected CSV output:\n%s", buffer.String())
+	}
+}
@@ -0,0 +1,236 @@
+package review
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"timetrack-sync/src/sloneek"
+	"timetrack-sync/src/utils"
+)
+
+var ErrAborted = errors.New("Review aborted by user")
+
+// Reviewer walks through mapped entries day by day and lets the user decide about each of them.
+// It only reads lines from In, so the flow can be scripted in tests.
+type Reviewer struct {
+	In         io.Reader
+	Out        io.Writer
+	Activities []sloneek.Activity
+	Categories []sloneek.Category
+	Location   *time.Location
+
+	scanner *bufio.Scanner
+}
+
+func (reviewer *Reviewer) readLine(prompt string) (string, error) {
+	if reviewer.scanner == nil {
+		reviewer.scanner = bufio.NewScanner(reviewer.In)
+	}
+
+	fmt.Fprint(reviewer.Out, prompt)
+	if !reviewer.scanner.Scan() {
+		if err := reviewer.scanner.Err(); err != nil {
+			return "", err
+		}
+
+		// closed input means there is nobody to answer, nothing may be sent
+		return "", ErrAborted
+	}
+
+	return strings.TrimSpace(reviewer.scanner.Text()), nil
+}
+
+func (reviewer *Reviewer) describe(entry *sloneek.TimeEntry) string {
+	category := ""
+	if entry.CategoryId != nil {
+		category = " / " + sloneek.CategoryName(reviewer.Categories, entry.CategoryId)
+	}
+
+	return fmt.Sprintf("%s-%s %s%s",
+		entry.Since.In(reviewer.Location).Format("15:04"),
+		entry.Until.In(reviewer.Location).Format("15:04"),
+		sloneek.ActivityName(reviewer.Activities, entry.ActivityId),
+		category,
+	)
+}
+
+// Review returns the accepted, possibly edited, entries. ErrAborted is returned when the user
+// quits or the input ends before all entries were reviewed.
+func (reviewer *Reviewer) Review(entries []sloneek.TimeEntry) ([]sloneek.TimeEntry, error) {
+	sorted := slices.Clone(entries)
+	slices.SortStableFunc(sorted, func(a, b sloneek.TimeEntry) int { return a.Since.Compare(b.Since) })
+
+	accepted := []sloneek.TimeEntry{}
+	currentDay := ""
+	acceptDay := false
+	for i := range sorted {
+		entry := sorted[i]
+		day := utils.StartOfDay(entry.Since, reviewer.Location).Format("Mon 2006-01-02")
+		if day != currentDay {
+			currentDay = day
+			acceptDay = false
+			fmt.Fprintf(reviewer.Out, "\n%s\n", day)
+		}
+
+		if acceptDay {
+			fmt.Fprintf(reviewer.Out, "  %s (accepted)\n", reviewer.describe(&entry))
+			accepted = append(accepted, entry)
+			continue
+		}
+
+		decided := false
+		for !decided {
+			answer, err := reviewer.readLine(fmt.Sprintf("  %s\n  [a]ccept, [s]kip, [c]hange activity, [e]dit times, accept [d]ay, [q]uit: ", reviewer.describe(&entry)))
+			if err != nil {
+				return nil, err
+			}
+
+			switch answer {
+			case "a", "":
+				accepted = append(accepted, entry)
+				decided = true
+			case "s":
+				decided = true
+			case "d":
+				accepted = append(accepted, entry)
+				acceptDay = true
+				decided = true
+			case "c":
+				err = reviewer.changeActivity(&entry)
+			case "e":
+				err = reviewer.editTimes(&entry)
+			case "q":
+				return nil, ErrAborted
+			default:
+				fmt.Fprintf(reviewer.Out, "  Unknown choice %q\n", answer)
+			}
+
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return accepted, nil
+}
+
+// choose prints numbered options and returns the selected index, -1 for the optional zero choice.
+func (reviewer *Reviewer) choose(title string, options []string, noneOption string) (int, error) {
+	fmt.Fprintf(reviewer.Out, "  %s:\n", title)
+	if noneOption != "" {
+		fmt.Fprintf(reviewer.Out, "    0) %s\n", noneOption)
+	}
+	for i, option := range options {
+		fmt.Fprintf(reviewer.Out, "    %d) %s\n", i+1, option)
+	}
+
+	for {
+		answer, err := reviewer.readLine("  > ")
+		if err != nil {
+			return 0, err
+		}
+
+		choice, err := strconv.Atoi(answer)
+		if err == nil && choice >= 1 && choice <= len(options) {
+			return choice - 1, nil
+		}
+		if err == nil && choice == 0 && noneOption != "" {
+			return -1, nil
+		}
+
+		fmt.Fprintf(reviewer.Out, "  Invalid choice %q\n", answer)
+	}
+}
+
+func (reviewer *Reviewer) changeActivity(entry *sloneek.TimeEntry) error {
+	activityNames := make([]string, len(reviewer.Activities))
+	for i, activity := range reviewer.Activities {
+		activityNames[i] = activity.Name
+	}
+
+	activityIndex, err := reviewer.choose("Activity", activityNames, "")
+	if err != nil {
+		return err
+	}
+
+	categoryNames := make([]string, len(reviewer.Categories))
+	for i, category := range reviewer.Categories {
+		categoryNames[i] = category.Name
+	}
+
+	categoryIndex, err := reviewer.choose("Category", categoryNames, "No category")
+	if err != nil {
+		return err
+	}
+
+	entry.ActivityId = reviewer.Activities[activityIndex].Id
+	entry.CategoryId = nil
+	if categoryIndex != -1 {
+		categoryId := reviewer.Categories[categoryIndex].Id
+		entry.CategoryId = &categoryId
+	}
+
+	return nil
+}
+
+// parseClock parses HH:MM on the given day, empty value keeps the current time.
+func parseClock(value string, day time.Time, current time.Time, location *time.Location) (time.Time, error) {
+	if value == "" {
+		return current, nil
+	}
+
+	clock, err := time.Parse("15:04", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Date(day.Year(), day.Month(), day.Day(), clock.Hour(), clock.Minute(), 0, 0, location), nil
+}
+
+// parseEnd parses the end like parseClock, 24:00 and 00:00 end at midnight of the following day.
+func parseEnd(value string, day time.Time, current time.Time, location *time.Location) (time.Time, error) {
+	if value == "24:00" {
+		value = "00:00"
+	}
+
+	until, err := parseClock(value, day, current, location)
+	if err != nil || value == "" || !until.Equal(day) {
+		return until, err
+	}
+
+	return day.AddDate(0, 0, 1), nil
+}
+
+func (reviewer *Reviewer) editTimes(entry *sloneek.TimeEntry) error {
+	day := utils.StartOfDay(entry.Since, reviewer.Location)
+	for {
+		startAnswer, err := reviewer.readLine(fmt.Sprintf("  Start [%s]: ", entry.Since.In(reviewer.Location).Format("15:04")))
+		if err != nil {
+			return err
+		}
+		endAnswer, err := reviewer.readLine(fmt.Sprintf("  End [%s]: ", entry.Until.In(reviewer.Location).Format("15:04")))
+		if err != nil {
+			return err
+		}
+
+		since, startErr := parseClock(startAnswer, day, entry.Since, reviewer.Location)
+		until, endErr := parseEnd(endAnswer, day, entry.Until, reviewer.Location)
+		if startErr != nil || endErr != nil {
+			fmt.Fprintln(reviewer.Out, "  Times must be in HH:MM format")
+			continue
+		}
+		if !until.After(since) {
+			fmt.Fprintln(reviewer.Out, "  End must be after start")
+			continue
+		}
+
+		entry.Since = since
+		entry.Until = until
+		return nil
+	}
+}
@@ -0,0 +1,97 @@
+package review
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"timetrack-sync/src/sloneek"
+	testutils "timetrack-sync/src/testUtils"
+)
+
+func testReviewer(input string) *Reviewer {
+	return &Reviewer{
+		In:         strings.NewReader(input),
+		Out:        &bytes.Buffer{},
+		Activities: []sloneek.Activity{{Id: "a1", Name: "Vývoj"}, {Id: "a2", Name: "Meeting"}},
+		Categories: []sloneek.Category{{Id: "c1", Name: "Proteus"}, {Id: "c2", Name: "Flexi"}},
+		Location:   time.UTC,
+	}
+}
+
+func testEntries(t *testing.T) []sloneek.TimeEntry {
+	t.Helper()
+	categoryId := "c1"
+	return []sloneek.TimeEntry{
+		{SourceId: 1, ActivityId: "a1", CategoryId: &categoryId, Since: testutils.DateTimeFromString("2024-09-02 08:00:00", t), Until: testutils.DateTimeFromString("2024-09-02 10:00:00", t)},
+		{SourceId: 2, ActivityId: "a2", Since: testutils.DateTimeFromString("2024-09-02 10:00:00", t), Until: testutils.DateTimeFromString("2024-09-02 11:00:00", t)},
+		{SourceId: 3, ActivityId: "a1", Since: testutils.DateTimeFromString("2024-09-03 08:00:00", t), Until: testutils.DateTimeFromString("2024-09-03 09:00:00", t)},
+		{SourceId: 4, ActivityId: "a1", Since: testutils.DateTimeFromString("2024-09-03 09:00:00", t), Until: testutils.DateTimeFromString("2024-09-03 10:00:00", t)},
+	}
+}
+
+func TestReviewScriptedSession(t *testing.T) {
+	// entry 1: change to Meeting without category, then accept
+	// entry 2: invalid answer, then edit times keeping start, then accept
+	// entry 3: skip
+	// entry 4: accept the rest of the day
+	input := strings.Join([]string{
+		"c", "2", "0", "a",
+		"x", "e", "", "11:30", "a",
+		"s",
+		"d",
+	}, "\n") + "\n"
+
+	result, err := testReviewer(input).Review(testEntries(t))
+	if err != nil {
+		t.Fatalf("Review returned unexpected error: %v", err)
+	}
+
+	if len(result) != 3 {
+		t.Fatalf("Expected 3 accepted entries, got %v", result)
+	}
+	if result[0].ActivityId != "a2" || result[0].CategoryId != nil {
+		t.Errorf("Expected first entry changed to Meeting without category, got %v", result[0])
+	}
+	if !result[1].Until.Equal(testutils.DateTimeFromString("2024-09-02 11:30:00", t)) || !result[1].Since.Equal(testutils.DateTimeFromString("2024-09-02 10:00:00", t)) {
+		t.Errorf("Expected second entry to end at 11:30, got %v", result[1])
+	}
+	if result[2].SourceId != 4 {
+		t.Errorf("Expected last day entry to be accepted, got %v", result[2])
+	}
+}
+
+func TestReviewRejectsEndBeforeStart(t *testing.T) {
+	input := strings.Join([]string{"e", "10:00", "09:00", "08:30", "09:15", "a", "q"}, "\n") + "\n"
+
+	reviewer := testReviewer(input)
+	_, err := reviewer.Review(testEntries(t))
+	if !errors.Is(err, ErrAborted) {
+		t.Errorf("Expected ErrAborted, got %v", err)
+	}
+	if !strings.Contains(reviewer.Out.(*bytes.Buffer).String(), "End must be after start") {
+		t.Errorf("Expected validation message in output")
+	}
+}
+
+func TestReviewEndsAtMidnight(t *testing.T) {
+	for _, end := range []string{"24:00", "00:00"} {
+		input := strings.Join([]string{"e", "22:00", end, "a", "d", "d"}, "\n") + "\n"
+
+		result, err := testReviewer(input).Review(testEntries(t))
+		if err != nil {
+			t.Fatalf("%s: Review returned unexpected error: %v", end, err)
+		}
+		if !result[0].Since.Equal(testutils.DateTimeFromString("2024-09-02 22:00:00", t)) || !result[0].Until.Equal(testutils.DateTimeFromString("2024-09-03 00:00:00", t)) {
+			t.Errorf("%s: Expected the entry to end at midnight of the following day, got %v", end, result[0])
+		}
+	}
+}
+
+func TestReviewAbortsOnClosedInput(t *testing.T) {
+	_, err := testReviewer("a\n").Review(testEntries(t))
+	if !errors.Is(err, ErrAborted) {
+		t.Errorf("Expected ErrAborted, got %v", err)
+	}
+}
@@ -7,6 +7,11 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -15,13 +20,15 @@ import (
 type SloneekClient struct {
 	apiUrl      string
 	bearerToken string
-	httpClient  *http.Client
-	logger      *zerolog.Logger
+	// userUuid selects whose scheduled events are read and written, empty means the token owner
+	userUuid   string
+	httpClient *http.Client
+	logger     *zerolog.Logger
 }
 
 type Category struct {
 	Id   string `json:"uuid"`
-	Name string `jons:"name"`
+	Name string `json:"name"`
 }
 
 type CategoriesResponse struct {
@@ -30,41 +37,79 @@ type CategoriesResponse struct {
 	Data        []Category `json:"data"`
 }
 
-// zajimaj me hlavne "Meeting", "Hiring", "Vývoj"
-func (client *SloneekClient) GetCategories() []Category {
-	client.logger.Info().Msg("Looking up Sloneek categories")
-	categoriesUrl := fmt.Sprintf("%s/v2/module-planning/scheduled-events/options/categories", client.apiUrl)
-	req, err := http.NewRequest(http.MethodGet, categoriesUrl, nil)
+var ErrAuthenticationFailed = errors.New("Sloneek authentication failed")
+
+type StatusError struct {
+	StatusCode int
+}
+
+func (err *StatusError) Error() string {
+	return fmt.Sprintf("Sloneek responded with status %d", err.StatusCode)
+}
+
+// get sends an authenticated GET request and unmarshals the JSON response into target.
+func (client *SloneekClient) get(endpointUrl string, target any) error {
+	req, err := http.NewRequest(http.MethodGet, endpointUrl, nil)
 	if err != nil {
-		client.logger.Fatal().Err(err).Msg("Nepovedlo se udelat categories request")
-		return nil
+		client.logger.Error().Err(err).Msg("Error while creating request.")
+		return err
 	}
 
 	client.authenticateRequest(req)
 	res, err := client.httpClient.Do(req)
 	if err != nil {
-		client.logger.Fatal().Err(err).Msg("Nepovedlo se poslat categories request")
-		return nil
+		client.logger.Error().Err(err).Msg("Error while sending request.")
+		return err
 	}
+	defer res.Body.Close()
 
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
-		client.logger.Fatal().Err(err).Msg("Error while reading response body.")
-		return nil
+		client.logger.Error().Err(err).Msg("Error while reading response body.")
+		return err
+	}
+
+	client.logger.Debug().Str("body", fmt.Sprintf("%s", body)).Msg("Prisla mi odpoved")
+	if res.StatusCode == 401 || res.StatusCode == 403 {
+		client.logger.Error().Int("status_code", res.StatusCode).Msg("Authentication failed")
+		return ErrAuthenticationFailed
+	}
+	if res.StatusCode != 200 {
+		client.logger.Error().Int("status_code", res.StatusCode).Msg("Non-200 response received")
+		return &StatusError{StatusCode: res.StatusCode}
+	}
+
+	err = json.Unmarshal(body, target)
+	if err != nil {
+		client.logger.Error().Err(err).Msg("Error while unmarshaling response payload.")
+		return err
 	}
 
-	client.logger.Debug().Str("body", fmt.Sprintf("%s", body)).Msg("Prisla mi categories odpoved")
+	return nil
+}
+
+// zajimaj me hlavne "Meeting", "Hiring", "Vývoj"
+func (client *SloneekClient) GetCategories() ([]Category, error) {
+	client.logger.Info().Msg("Looking up Sloneek categories")
+	categoriesUrl := fmt.Sprintf("%s/v2/module-planning/scheduled-events/options/categories", client.apiUrl)
+
 	var categoriesPayload CategoriesResponse
-	err = json.Unmarshal(body, &categoriesPayload)
+	err := client.get(categoriesUrl, &categoriesPayload)
 	if err != nil {
-		client.logger.Fatal().Err(err).Msg("Error while unmarshaling response body payload.")
-		return nil
+		return nil, err
 	}
 
 	categories := categoriesPayload.Data
 	client.logger.Debug().Any("sloneek_categories", categories).Msg("Got sloneek categories")
 	client.logger.Info().Msg("Categories found.")
-	return categories
+	return categories, nil
+}
+
+// CheckConnection lists planning categories, the smallest Sloneek lookup that needs a valid JWT.
+// An expired token gives ErrAuthenticationFailed.
+func (client *SloneekClient) CheckConnection() error {
+	_, err := client.GetCategories()
+	return err
 }
 
 type PlanningEvent struct {
@@ -88,33 +133,39 @@ type Activity struct {
 	Name string
 }
 
-func (client *SloneekClient) GetActivities() []Activity {
-	client.logger.Info().Msg("Looking up Sloneek activities")
-	endpointUrl := fmt.Sprintf("%s/v2/module-planning/scheduled-events/options/user-planning-events", client.apiUrl)
-	req, err := http.NewRequest(http.MethodGet, endpointUrl, nil)
-	if err != nil {
-		client.logger.Fatal().Err(err).Msg("Error while creating request.")
-		return nil
+// ActivityName returns the name of the activity with given ID, or the ID itself when not found.
+func ActivityName(activities []Activity, activityId string) string {
+	index := slices.IndexFunc(activities, func(activity Activity) bool { return activity.Id == activityId })
+	if index == -1 {
+		return activityId
 	}
 
-	client.authenticateRequest(req)
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		client.logger.Fatal().Err(err).Msg("Error while sending request.")
-		return nil
+	return activities[index].Name
+}
+
+// CategoryName returns the name of the category with given ID, or the ID itself when not found.
+// Entries without category are reported as "Uncategorized".
+func CategoryName(categories []Category, categoryId *string) string {
+	if categoryId == nil {
+		return "Uncategorized"
 	}
 
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		client.logger.Fatal().Err(err).Msg("Error while reading response body.")
-		return nil
+	index := slices.IndexFunc(categories, func(category Category) bool { return category.Id == *categoryId })
+	if index == -1 {
+		return *categoryId
 	}
 
-	client.logger.Debug().Str("body", fmt.Sprintf("%s", body)).Msg("Prisla mi odpoved")
+	return categories[index].Name
+}
+
+func (client *SloneekClient) GetActivities() ([]Activity, error) {
+	client.logger.Info().Msg("Looking up Sloneek activities")
+	endpointUrl := fmt.Sprintf("%s/v2/module-planning/scheduled-events/options/user-planning-events", client.apiUrl)
+
 	var payload OptionsResponse
-	err = json.Unmarshal(body, &payload)
+	err := client.get(endpointUrl, &payload)
 	if err != nil {
-		client.logger.Fatal().Err(err).Msg("Error while unmarshaling response payload.")
+		return nil, err
 	}
 
 	client.logger.Debug().Any("payload_data", payload.Data)
@@ -128,8 +179,7 @@ func (client *SloneekClient) GetActivities() []Activity {
 
 	client.logger.Debug().Any("sloneek_activities", activities).Msg("Got sloneek activities")
 	client.logger.Info().Msg("Activities found.")
-	return activities
-
+	return activities, nil
 }
 
 func CreateSloneekClient(apiUrl string, bearerToken string, logger *zerolog.Logger) *SloneekClient {
@@ -138,22 +188,77 @@ func CreateSloneekClient(apiUrl string, bearerToken string, logger *zerolog.Logg
 	return &SloneekClient{apiUrl: apiUrl, bearerToken: bearerToken, logger: logger, httpClient: &httpClient}
 }
 
+// SetUserUuid makes the client work with scheduled events of the given Sloneek user.
+func (client *SloneekClient) SetUserUuid(userUuid string) {
+	client.userUuid = userUuid
+}
+
+// SetHttpClient sends Sloneek requests through the client the app got from WithHttpClient.
+func (client *SloneekClient) SetHttpClient(httpClient *http.Client) {
+	client.httpClient = httpClient
+}
+
 func (client *SloneekClient) authenticateRequest(req *http.Request) {
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", client.bearerToken))
 }
 
 type TimeEntry struct {
+	// Id of the scheduled event, empty for entries not saved to Sloneek yet
+	Id string
+	// SourceId is the ID of the Toggl time entry the entry was mapped from, zero when unknown.
+	// It is stored at the end of the Sloneek note.
+	SourceId   int64
 	ActivityId string
 	CategoryId *string
 	note       string
 	Since      time.Time
 	Until      time.Time
+	// AutoApprove asks Sloneek to approve the saved entry right away
+	AutoApprove bool
+	// ApprovalStatus is reported by Sloneek for stored entries, e.g. "pending" or "approved"
+	ApprovalStatus string
 }
 
 func (entry *TimeEntry) GetHours() float64 {
 	return entry.Until.Sub(entry.Since).Hours()
 }
 
+func (entry *TimeEntry) GetNote() string {
+	return entry.note
+}
+
+func (entry *TimeEntry) SetNote(note string) {
+	entry.note = note
+}
+
+// sourcePattern finds the Toggl entry ID appended to notes of synced entries. Entries without
+// it were entered in Sloneek directly or by an older version and are never deleted by a sync.
+var sourcePattern = regexp.MustCompile(`\s*\[toggl:(\d+)\]$`)
+
+// noteWithSource appends the Toggl entry ID to the note, so stored entries can be traced back.
+func noteWithSource(note string, sourceId int64) string {
+	if sourceId == 0 {
+		return note
+	}
+
+	return strings.TrimSpace(fmt.Sprintf("%s [toggl:%d]", note, sourceId))
+}
+
+// splitSource separates a note written by noteWithSource into the note and the Toggl entry ID.
+func splitSource(note string) (string, int64) {
+	match := sourcePattern.FindStringSubmatch(note)
+	if match == nil {
+		return note, 0
+	}
+
+	sourceId, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return note, 0
+	}
+
+	return note[:len(note)-len(match[0])], sourceId
+}
+
 func (entry *TimeEntry) GetProjectId() string {
 	if entry.CategoryId != nil {
 		return *entry.CategoryId
@@ -163,6 +268,7 @@ func (entry *TimeEntry) GetProjectId() string {
 }
 
 type TimeEntryDTO struct {
+	UserUuid               string    `json:"user_uuid,omitempty"`
 	UserPlanningEventUuid  string    `json:"user_planning_event_uuid"`
 	PlanningCategories     []string  `json:"planning_categories"`
 	StartedAt              time.Time `json:"started_at"`
@@ -175,6 +281,63 @@ type TimeEntryDTO struct {
 
 func (client *SloneekClient) SaveTimeEntry(timeEntry *TimeEntry) error {
 	client.logger.Info().Any("time_entry", timeEntry).Msg("Saving Sloneek time entry")
+	endpointUrl := fmt.Sprintf("%s/v2/module-planning/scheduled-events", client.apiUrl)
+	err := client.sendTimeEntry(http.MethodPost, endpointUrl, timeEntry)
+	if err != nil {
+		return err
+	}
+
+	client.logger.Info().Msg("Time entry saved")
+	return nil
+}
+
+// UpdateTimeEntry overwrites the scheduled event identified by the entry Id.
+func (client *SloneekClient) UpdateTimeEntry(timeEntry *TimeEntry) error {
+	client.logger.Info().Any("time_entry", timeEntry).Msg("Updating Sloneek time entry")
+	if timeEntry == nil || timeEntry.Id == "" {
+		err := errors.New("Time entry to update must have an ID")
+		client.logger.Err(err).Msg("Error while updating time entry")
+		return err
+	}
+
+	endpointUrl := fmt.Sprintf("%s/v2/module-planning/scheduled-events/%s", client.apiUrl, url.PathEscape(timeEntry.Id))
+	err := client.sendTimeEntry(http.MethodPut, endpointUrl, timeEntry)
+	if err != nil {
+		return err
+	}
+
+	client.logger.Info().Msg("Time entry updated")
+	return nil
+}
+
+func (client *SloneekClient) DeleteTimeEntry(id string) error {
+	client.logger.Info().Str("id", id).Msg("Deleting Sloneek time entry")
+	endpointUrl := fmt.Sprintf("%s/v2/module-planning/scheduled-events/%s", client.apiUrl, url.PathEscape(id))
+	req, err := http.NewRequest(http.MethodDelete, endpointUrl, nil)
+	if err != nil {
+		client.logger.Error().Err(err).Msg("Error while creating request.")
+		return err
+	}
+
+	client.authenticateRequest(req)
+	res, err := client.httpClient.Do(req)
+	if err != nil {
+		client.logger.Error().Err(err).Msg("Error while sending request.")
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != 200 && res.StatusCode != 204 {
+		message := "Unexpected response received"
+		client.logger.Error().Int("status_code", res.StatusCode).Msg(message)
+		return errors.New(message)
+	}
+
+	client.logger.Info().Msg("Time entry deleted")
+	return nil
+}
+
+func (client *SloneekClient) sendTimeEntry(method string, endpointUrl string, timeEntry *TimeEntry) error {
 	if timeEntry == nil {
 		err := errors.New("Time entry to save may not be nil")
 		client.logger.Err(err).Msg("Error while saving time entry")
@@ -187,15 +350,15 @@ func (client *SloneekClient) SaveTimeEntry(timeEntry *TimeEntry) error {
 	}
 
 	dto := &TimeEntryDTO{
-		UserPlanningEventUuid: timeEntry.ActivityId,
-		PlanningCategories:    planningCategories,
-		StartedAt:             timeEntry.Since,
-		StartTime:             timeEntry.Since,
-		EndedAt:               timeEntry.Until,
-		EndTime:               timeEntry.Until,
-		Note:                  timeEntry.note,
-		// always setting to false, not realy does anything
-		IsAutomaticallyApprove: false,
+		UserUuid:               client.userUuid,
+		UserPlanningEventUuid:  timeEntry.ActivityId,
+		PlanningCategories:     planningCategories,
+		StartedAt:              timeEntry.Since,
+		StartTime:              timeEntry.Since,
+		EndedAt:                timeEntry.Until,
+		EndTime:                timeEntry.Until,
+		Note:                   noteWithSource(timeEntry.note, timeEntry.SourceId),
+		IsAutomaticallyApprove: timeEntry.AutoApprove,
 	}
 
 	payload, err := json.Marshal(*dto)
@@ -204,34 +367,92 @@ func (client *SloneekClient) SaveTimeEntry(timeEntry *TimeEntry) error {
 		return err
 	}
 
-	endpointUrl := fmt.Sprintf("%s/v2/module-planning/scheduled-events", client.apiUrl)
 	client.logger.Debug().Any("payload", payload).Any("DTO", dto).Str("endpoint_url", endpointUrl).Msg("Sending payload")
-	req, err := http.NewRequest(http.MethodPost, endpointUrl, bytes.NewBuffer(payload))
+	req, err := http.NewRequest(method, endpointUrl, bytes.NewBuffer(payload))
 	if err != nil {
-		client.logger.Fatal().Err(err).Msg("Nepovedlo se udelat request")
+		client.logger.Error().Err(err).Msg("Nepovedlo se udelat request")
 		return err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 	client.authenticateRequest(req)
-	res, err := http.DefaultClient.Do(req)
+	res, err := client.httpClient.Do(req)
 	if err != nil {
-		client.logger.Fatal().Err(err).Msg("Error while sending request.")
+		client.logger.Error().Err(err).Msg("Error while sending request.")
 		return err
 	}
+	defer res.Body.Close()
 
 	if res.StatusCode != 200 {
 		body, err := io.ReadAll(res.Body)
 		if err != nil {
-			client.logger.Fatal().Err(err).Msg("Error while reading response body.")
+			client.logger.Error().Err(err).Msg("Error while reading response body.")
 			return err
 		}
 
-		message := "Non-200 response received"
-		client.logger.Error().Int("status_code", res.StatusCode).Str("body", fmt.Sprintf("%s", body)).Msg(message)
-		return errors.New(message)
+		client.logger.Error().Int("status_code", res.StatusCode).Str("body", fmt.Sprintf("%s", body)).Msg("Non-200 response received")
+		return &StatusError{StatusCode: res.StatusCode}
 	}
 
-	client.logger.Info().Msg("Time entry saved")
 	return nil
 }
+
+type ScheduledEventDTO struct {
+	Uuid              string `json:"uuid"`
+	UserPlanningEvent struct {
+		Uuid string `json:"uuid"`
+	} `json:"user_planning_event"`
+	PlanningCategories []Category `json:"planning_categories"`
+	StartedAt          time.Time  `json:"started_at"`
+	EndedAt            time.Time  `json:"ended_at"`
+	Note               string     `json:"note"`
+	ApprovalStatus     string     `json:"approval_status,omitempty"`
+}
+
+type ScheduledEventsResponse struct {
+	Message     string              `json:"message"`
+	Status_code int32               `json:"status_code"`
+	Data        []ScheduledEventDTO `json:"data"`
+}
+
+// GetTimeEntries looks up scheduled events already stored in Sloneek for the given range.
+func (client *SloneekClient) GetTimeEntries(since time.Time, until time.Time) ([]TimeEntry, error) {
+	client.logger.Info().Any("since", since).Any("until", until).Msg("Looking up Sloneek time entries")
+	query := url.Values{}
+	query.Set("started_at", since.Format(time.RFC3339))
+	query.Set("ended_at", until.Format(time.RFC3339))
+	if client.userUuid != "" {
+		query.Set("user_uuid", client.userUuid)
+	}
+	endpointUrl := fmt.Sprintf("%s/v2/module-planning/scheduled-events?%s", client.apiUrl, query.Encode())
+
+	var payload ScheduledEventsResponse
+	err := client.get(endpointUrl, &payload)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]TimeEntry, len(payload.Data))
+	for i, event := range payload.Data {
+		var categoryId *string
+		if len(event.PlanningCategories) > 0 {
+			categoryId = &event.PlanningCategories[0].Id
+		}
+
+		note, sourceId := splitSource(event.Note)
+		entries[i] = TimeEntry{
+			Id:             event.Uuid,
+			SourceId:       sourceId,
+			ActivityId:     event.UserPlanningEvent.Uuid,
+			CategoryId:     categoryId,
+			note:           note,
+			Since:          event.StartedAt,
+			Until:          event.EndedAt,
+			ApprovalStatus: event.ApprovalStatus,
+		}
+	}
+
+	client.logger.Debug().Any("sloneek_entries", entries).Msg("Got sloneek time entries")
+	client.logger.Info().Int("count", len(entries)).Msg("Time entries found.")
+	return entries, nil
+}
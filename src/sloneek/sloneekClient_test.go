@@ -1,8 +1,15 @@
 package sloneek
 
 import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
+	"timetrack-sync/src/fakeapi"
 	testutils "timetrack-sync/src/testUtils"
+
+	"github.com/rs/zerolog"
 )
 
 func TestGetHoursReturnsCorrectFullHour(t *testing.T) {
@@ -34,3 +41,150 @@ func TestGetHoursReturnsCorrectPartialHour(t *testing.T) {
 		t.Errorf("Unexpected hours value found. Expected: %f, got: %f", result, expectedHours)
 	}
 }
+
+func startFakeSloneek(t *testing.T) (*fakeapi.Sloneek, *SloneekClient) {
+	t.Helper()
+	fake := fakeapi.CreateSloneek(fakeapi.SloneekState{
+		Bearer:     "jwt",
+		Categories: []fakeapi.SloneekCategory{{Uuid: "c1", Name: "Meeting"}},
+		Activities: []fakeapi.SloneekActivity{{Uuid: "a1", PlanningEventUuid: "p1", Name: "Development"}},
+		Events: []fakeapi.SloneekEvent{{
+			Uuid:          "existing",
+			UserUuid:      "someone-else",
+			ActivityUuid:  "a1",
+			StartedAt:     time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC),
+			EndedAt:       time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC),
+			CategoryUuids: []string{"c1"},
+		}},
+	})
+
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	logger := zerolog.Nop()
+	client := CreateSloneekClient(server.URL, "jwt", &logger)
+	client.SetUserUuid("me")
+	return fake, client
+}
+
+func TestGetActivitiesAndCategories(t *testing.T) {
+	_, client := startFakeSloneek(t)
+
+	activities, err := client.GetActivities()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the user planning event UUID is used, not the shared planning event one
+	if len(activities) != 1 || activities[0].Id != "a1" || activities[0].Name != "Development" {
+		t.Errorf("unexpected activities %v", activities)
+	}
+
+	categories, err := client.GetCategories()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(categories) != 1 || categories[0].Id != "c1" || categories[0].Name != "Meeting" {
+		t.Errorf("unexpected categories %v", categories)
+	}
+}
+
+func TestTimeEntryLifecycle(t *testing.T) {
+	fake, client := startFakeSloneek(t)
+	since := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	categoryId := "c1"
+	entry := &TimeEntry{SourceId: 42, ActivityId: "a1", CategoryId: &categoryId, Since: since, Until: since.Add(90 * time.Minute)}
+	entry.SetNote("Review")
+
+	err := client.SaveTimeEntry(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// only events of the configured user are read
+	entries, err := client.GetTimeEntries(since.Add(-24*time.Hour), since.Add(24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected the saved entry only, got %v", entries)
+	}
+	saved := entries[0]
+	if saved.Id == "" || saved.ActivityId != "a1" || saved.CategoryId == nil || *saved.CategoryId != "c1" || saved.GetNote() != "Review" || saved.SourceId != 42 || saved.GetHours() != 1.5 {
+		t.Errorf("unexpected saved entry %+v", saved)
+	}
+
+	if fake.Events()[1].Note != "Review [toggl:42]" {
+		t.Errorf("expected the Toggl entry in the stored note, got %q", fake.Events()[1].Note)
+	}
+
+	saved.Until = since.Add(2 * time.Hour)
+	err = client.UpdateTimeEntry(&saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := fake.Events()
+	if !events[1].EndedAt.Equal(saved.Until) || events[1].UserUuid != "me" {
+		t.Errorf("expected updated event of the user, got %+v", events[1])
+	}
+
+	err = client.DeleteTimeEntry(saved.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.Events()) != 1 {
+		t.Errorf("expected the event deleted, got %v", fake.Events())
+	}
+}
+
+func TestUpdateOfMissingEventFails(t *testing.T) {
+	_, client := startFakeSloneek(t)
+
+	entry := &TimeEntry{Id: "missing", ActivityId: "a1", Since: time.Now(), Until: time.Now().Add(time.Hour)}
+	err := client.UpdateTimeEntry(entry)
+
+	var statusError *StatusError
+	if !errors.As(err, &statusError) || statusError.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status 404, got %v", err)
+	}
+}
+
+func TestSloneekInjectedFaults(t *testing.T) {
+	fake, client := startFakeSloneek(t)
+
+	fake.Inject(fakeapi.Fault{Status: http.StatusUnauthorized, Times: 1})
+	err := client.CheckConnection()
+	if !errors.Is(err, ErrAuthenticationFailed) {
+		t.Errorf("expected ErrAuthenticationFailed, got %v", err)
+	}
+
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusInternalServerError} {
+		fake.Inject(fakeapi.Fault{Status: status, Times: 1})
+		entry := &TimeEntry{ActivityId: "a1", Since: time.Now(), Until: time.Now().Add(time.Hour)}
+		err = client.SaveTimeEntry(entry)
+
+		var statusError *StatusError
+		if !errors.As(err, &statusError) || statusError.StatusCode != status {
+			t.Errorf("expected status %d, got %v", status, err)
+		}
+	}
+	if len(fake.Events()) != 1 {
+		t.Errorf("failed saves must not store events, got %v", fake.Events())
+	}
+
+	fake.Inject(fakeapi.Fault{Delay: time.Second})
+	client.SetHttpClient(&http.Client{Timeout: 50 * time.Millisecond})
+	_, err = client.GetActivities()
+	if err == nil {
+		t.Error("expected a timeout")
+	}
+}
+
+func TestWrongBearerFailsAuthentication(t *testing.T) {
+	_, client := startFakeSloneek(t)
+	client.bearerToken = "expired"
+
+	_, err := client.GetTimeEntries(time.Now(), time.Now())
+	if !errors.Is(err, ErrAuthenticationFailed) {
+		t.Errorf("expected ErrAuthenticationFailed, got %v", err)
+	}
+}
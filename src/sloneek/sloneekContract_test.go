@@ -0,0 +1,146 @@
+package sloneek
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"timetrack-sync/src/httpfixture"
+
+	"github.com/rs/zerolog"
+)
+
+// fixtureSince and fixtureUntil limit the scheduled events looked up when the fixture is recorded
+var fixtureSince, fixtureUntil = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+
+// sampleFixture is written by hand in the recorder's format after the Sloneek API documentation,
+// it is not a recording. Once TestRecordExchanges wrote recordedFixture, tests replay that instead.
+var (
+	sampleFixture   = filepath.Join("testdata", "sloneek_sample_exchanges.json")
+	recordedFixture = filepath.Join("testdata", "sloneek_exchanges.json")
+)
+
+func exchangesFixture() string {
+	if _, err := os.Stat(recordedFixture); err == nil {
+		return recordedFixture
+	}
+
+	return sampleFixture
+}
+
+func replayingClient(t *testing.T) *SloneekClient {
+	t.Helper()
+	replayer, err := httpfixture.ReadReplayer(exchangesFixture())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := zerolog.Nop()
+	client := CreateSloneekClient("https://sloneek.invalid", "replayed", &logger)
+	client.SetHttpClient(&http.Client{Transport: replayer})
+	return client
+}
+
+// TestRecordExchanges records recordedFixture from the real API, run with
+// SLONEEK_RECORD_BEARER=<token> go test ./src/sloneek -run TestRecordExchanges
+func TestRecordExchanges(t *testing.T) {
+	bearer := os.Getenv("SLONEEK_RECORD_BEARER")
+	if bearer == "" {
+		t.Skip("SLONEEK_RECORD_BEARER not set")
+	}
+
+	recorder := httpfixture.CreateRecorder(recordedFixture, bearer)
+	recorder.Redactor.AddFields("email", "first_name", "last_name")
+	logger := zerolog.Nop()
+	client := CreateSloneekClient("https://api2.sloneek.com", bearer, &logger)
+	client.SetHttpClient(&http.Client{Timeout: time.Minute, Transport: recorder})
+
+	_, err := client.GetCategories()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.GetActivities()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.GetTimeEntries(fixtureSince, fixtureUntil)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFixtureResponsesMatchClientTypes(t *testing.T) {
+	exchanges, err := httpfixture.Read(exchangesFixture())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	types := map[string]any{
+		"/v2/module-planning/scheduled-events/options/categories":           CategoriesResponse{},
+		"/v2/module-planning/scheduled-events/options/user-planning-events": OptionsResponse{},
+		"/v2/module-planning/scheduled-events":                              ScheduledEventsResponse{},
+	}
+	checked := 0
+	for _, exchange := range exchanges {
+		requestUrl, err := url.Parse(exchange.Request.Url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		target, ok := types[requestUrl.Path]
+		if !ok || exchange.Request.Method != http.MethodGet || exchange.Response.Status != http.StatusOK {
+			continue
+		}
+
+		missing, err := httpfixture.MissingFields(exchange.Response.Body, target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(missing) > 0 {
+			t.Errorf("%s: fields missing in the fixture response: %v", requestUrl.Path, missing)
+		}
+		checked++
+	}
+
+	if checked != len(types) {
+		t.Errorf("expected a fixture response of every type, checked %d", checked)
+	}
+}
+
+func TestReplayedLookups(t *testing.T) {
+	client := replayingClient(t)
+
+	categories, err := client.GetCategories()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, category := range categories {
+		if category.Id == "" || category.Name == "" {
+			t.Errorf("incomplete category %+v", category)
+		}
+	}
+
+	activities, err := client.GetActivities()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, activity := range activities {
+		if activity.Id == "" || activity.Name == "" {
+			t.Errorf("incomplete activity %+v", activity)
+		}
+	}
+
+	entries, err := client.GetTimeEntries(fixtureSince, fixtureUntil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) == 0 {
+		t.Fatal("expected time entries in the fixture")
+	}
+	for _, entry := range entries {
+		if entry.Id == "" || entry.ActivityId == "" || !entry.Until.After(entry.Since) {
+			t.Errorf("incomplete time entry %+v", entry)
+		}
+	}
+}
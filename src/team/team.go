@@ -0,0 +1,82 @@
+package team
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"timetrack-sync/src/utils"
+)
+
+type User struct {
+	Name          string `json:"name"`
+	TogglApiKey   string `json:"toggl_api_key"`
+	SloneekBearer string `json:"sloneek_bearer"`
+	// SloneekUserUuid is needed when the bearer token belongs to someone else, e.g. the team lead
+	SloneekUserUuid string `json:"sloneek_user_uuid,omitempty"`
+	Profile         string `json:"profile,omitempty"`
+}
+
+// Config lists team members and named project mapping profiles they can refer to.
+// Users without a profile use the default mapping.
+type Config struct {
+	Profiles map[string]utils.ProjectMapping `json:"profiles"`
+	Users    []User                          `json:"users"`
+}
+
+func Load(path string) (*Config, error) {
+	payload, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config Config
+	err = json.Unmarshal(payload, &config)
+	if err != nil {
+		return nil, err
+	}
+
+	err = config.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+func (config *Config) Validate() error {
+	if len(config.Users) == 0 {
+		return errors.New("Team config has no users")
+	}
+
+	names := make(map[string]bool)
+	problems := []error{}
+	for i, user := range config.Users {
+		if user.Name == "" {
+			problems = append(problems, fmt.Errorf("User %d has no name", i+1))
+		} else if names[user.Name] {
+			problems = append(problems, fmt.Errorf("User %q is listed twice", user.Name))
+		}
+		names[user.Name] = true
+
+		if user.TogglApiKey == "" {
+			problems = append(problems, fmt.Errorf("User %q has no Toggl API key", user.Name))
+		}
+		if user.SloneekBearer == "" {
+			problems = append(problems, fmt.Errorf("User %q has no Sloneek bearer token", user.Name))
+		}
+		if _, ok := config.Profiles[user.Profile]; user.Profile != "" && !ok {
+			problems = append(problems, fmt.Errorf("User %q refers to unknown profile %q", user.Name, user.Profile))
+		}
+	}
+
+	return errors.Join(problems...)
+}
+
+func (config *Config) MappingFor(user *User) utils.ProjectMapping {
+	if user.Profile == "" {
+		return utils.DefaultProjectMapping
+	}
+
+	return config.Profiles[user.Profile]
+}
@@ -0,0 +1,72 @@
+package team
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"timetrack-sync/src/utils"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "team.json")
+	err := os.WriteFile(path, []byte(content), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoadResolvesProfiles(t *testing.T) {
+	path := writeConfig(t, `{
+		"profiles": {"design": {"Figma": {"activity": "Design"}}},
+		"users": [
+			{"name": "Alice", "toggl_api_key": "a", "sloneek_bearer": "b"},
+			{"name": "Bob", "toggl_api_key": "c", "sloneek_bearer": "d", "sloneek_user_uuid": "u", "profile": "design"}
+		]
+	}`)
+
+	config, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned unexpected error: %v", err)
+	}
+
+	activity, _ := config.MappingFor(&config.Users[0]).Lookup("Proteus")
+	if activity != "Vývoj" {
+		t.Errorf("Expected default mapping for Alice, got %q", activity)
+	}
+
+	mapping := config.MappingFor(&config.Users[1])
+	if activity, _ := mapping.Lookup("Figma"); activity != "Design" {
+		t.Errorf("Expected design profile for Bob, got %q", activity)
+	}
+	if _, ok := mapping["Proteus"]; ok {
+		t.Errorf("Profiles must not inherit the default mapping")
+	}
+	if config.Users[1].SloneekUserUuid != "u" {
+		t.Errorf("Unexpected user UUID %q", config.Users[1].SloneekUserUuid)
+	}
+}
+
+func TestValidateReportsAllProblems(t *testing.T) {
+	config := &Config{
+		Profiles: map[string]utils.ProjectMapping{},
+		Users: []User{
+			{Name: "Alice", TogglApiKey: "a"},
+			{Name: "Alice", TogglApiKey: "b", SloneekBearer: "c", Profile: "missing"},
+		},
+	}
+
+	err := config.Validate()
+	if err == nil {
+		t.Fatal("Expected to fail but did not fail")
+	}
+
+	for _, expected := range []string{"no Sloneek bearer token", "listed twice", "unknown profile"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error to contain %q, got %v", expected, err)
+		}
+	}
+}
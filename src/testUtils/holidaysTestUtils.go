@@ -0,0 +1,11 @@
+package testutils
+
+import "time"
+
+// FixedHolidays maps YYYY-MM-DD days to holiday names.
+type FixedHolidays map[string]string
+
+func (holidays FixedHolidays) Holiday(day time.Time) (string, bool) {
+	name, ok := holidays[day.Format(time.DateOnly)]
+	return name, ok
+}
@@ -14,3 +14,9 @@ func DateTimeFromString(value string, t *testing.T) time.Time {
 
 	return result
 }
+
+// DateFromString parses a YYYY-MM-DD day in UTC, invalid values give the zero time.
+func DateFromString(value string) time.Time {
+	parsed, _ := time.Parse(time.DateOnly, value)
+	return parsed
+}
@@ -2,9 +2,11 @@ package toggltrack
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -20,6 +22,16 @@ type TimeEntry struct {
 	Description string    `json:"description"`
 }
 
+var ErrAuthenticationFailed = errors.New("Toggl authentication failed")
+
+type StatusError struct {
+	StatusCode int
+}
+
+func (err *StatusError) Error() string {
+	return fmt.Sprintf("Toggl responded with status %d", err.StatusCode)
+}
+
 type TogglTrackClient struct {
 	apiUrl     string
 	apiKey     string
@@ -33,84 +45,94 @@ func CreateTogglTrackClient(apiUrl string, apiKey string, logger *zerolog.Logger
 	return &TogglTrackClient{apiUrl: apiUrl, apiKey: apiKey, logger: logger, httpClient: &httpClient}
 }
 
+// SetHttpClient lets WithHttpClient route Toggl requests through another transport in tests.
+func (client *TogglTrackClient) SetHttpClient(httpClient *http.Client) {
+	client.httpClient = httpClient
+}
+
 func (client *TogglTrackClient) authenticateRequest(req *http.Request) {
 	req.SetBasicAuth(client.apiKey, "api_token")
 }
 
-func (client *TogglTrackClient) GetTimeEntries(since time.Time, until time.Time) []TimeEntry {
-	client.logger.Info().Any("since", since).Any("until", until).Msg("Looking up Toggl time entries")
-	time_entries_url := fmt.Sprintf("%s/me/time_entries?start_date=%s&end_date=%s", client.apiUrl, since.Format(time.DateOnly), until.Format(time.DateOnly))
-	req, err := http.NewRequest(http.MethodGet, time_entries_url, nil)
+// get sends an authenticated GET request and unmarshals the JSON response into target.
+func (client *TogglTrackClient) get(endpointUrl string, target any) error {
+	req, err := http.NewRequest(http.MethodGet, endpointUrl, nil)
 	if err != nil {
-		client.logger.Fatal().Err(err).Msg("Error while creating request.")
+		client.logger.Error().Err(err).Msg("Error while creating request.")
+		return err
 	}
 
 	client.authenticateRequest(req)
 
 	res, err := client.httpClient.Do(req)
 	if err != nil {
-		client.logger.Fatal().Err(err).Msg("Error while sending request.")
+		client.logger.Error().Err(err).Msg("Error while sending request.")
+		return err
 	}
+	defer res.Body.Close()
 
 	client.logger.Debug().Str("status", res.Status).Msg("Prisla mi repsonse")
 	if res.StatusCode == 403 || res.StatusCode == 401 {
-		client.logger.Fatal().Str("api_key", client.apiKey).Msg("Authentication failed")
+		client.logger.Error().Int("status_code", res.StatusCode).Msg("Authentication failed")
+		return ErrAuthenticationFailed
 	}
 
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
-		client.logger.Fatal().Err(err).Msg("Error while reading response body.")
+		client.logger.Error().Err(err).Msg("Error while reading response body.")
+		return err
 	}
 	client.logger.Debug().Str("response_body", fmt.Sprintf("%s", body)).Msg("Prislo mi response body")
 
+	if res.StatusCode != 200 {
+		client.logger.Error().Int("status_code", res.StatusCode).Msg("Non-200 response received")
+		return &StatusError{StatusCode: res.StatusCode}
+	}
+
+	err = json.Unmarshal(body, target)
+	if err != nil {
+		client.logger.Error().Err(err).Msg("Error while unmarshaling response payload.")
+		return err
+	}
+
+	return nil
+}
+
+func (client *TogglTrackClient) GetTimeEntries(since time.Time, until time.Time) ([]TimeEntry, error) {
+	client.logger.Info().Any("since", since).Any("until", until).Msg("Looking up Toggl time entries")
+	time_entries_url := fmt.Sprintf("%s/me/time_entries?start_date=%s&end_date=%s", client.apiUrl, url.QueryEscape(since.Format(time.RFC3339)), url.QueryEscape(until.Format(time.RFC3339)))
+
 	var time_entries []TimeEntry
-	err = json.Unmarshal(body, &time_entries)
+	err := client.get(time_entries_url, &time_entries)
 	if err != nil {
-		client.logger.Fatal().Err(err).Msg("Error while unmarshaling response payload.")
+		return nil, err
 	}
 
 	client.logger.Info().Msg("Returning time entries.")
-	return time_entries
+	return time_entries, nil
 }
 
 type MePayload struct {
 	DefaultWorkspaceId int32 `json:"default_workspace_id"`
 }
 
-func (client *TogglTrackClient) GetDefaultWorkspaceId() int32 {
-	client.logger.Info().Msg("Looking up dwfault Toggl workspace ID")
+func (client *TogglTrackClient) GetDefaultWorkspaceId() (int32, error) {
+	client.logger.Info().Msg("Looking up default Toggl workspace ID")
 	meUrl := fmt.Sprintf("%s/me", client.apiUrl)
-	req, err := http.NewRequest(http.MethodGet, meUrl, nil)
-	if err != nil {
-		client.logger.Fatal().Err(err).Msg("Nepovedlo se vytvorit request")
-	}
-
-	client.authenticateRequest(req)
-
-	res, err := client.httpClient.Do(req)
-	if err != nil {
-		client.logger.Fatal().Err(err).Msg("Nepovedlo se poslat auth request")
-	}
 
-	client.logger.Info().Str("status", res.Status).Msg("Prisla mi repsonse")
-	if res.StatusCode == 403 || res.StatusCode == 401 {
-		client.logger.Fatal().Str("api_key", client.apiKey).Msg("Autentizace selhala")
-	}
-
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		client.logger.Fatal().Err(err).Msg("Nepovedlo se precist categories body")
-	}
-
-	client.logger.Info().Str("response_body", fmt.Sprintf("%s", body)).Msg("Prislo mi response body")
 	var payload MePayload
-	err = json.Unmarshal(body, &payload)
+	err := client.get(meUrl, &payload)
 	if err != nil {
-		client.logger.Fatal().Err(err).Msg("Error pri unmarshalingu toggl responsu")
+		return 0, err
 	}
 
-	return payload.DefaultWorkspaceId
+	return payload.DefaultWorkspaceId, nil
+}
 
+// CheckConnection reads the default workspace from /me, which Toggl rejects for a wrong API key.
+func (client *TogglTrackClient) CheckConnection() error {
+	_, err := client.GetDefaultWorkspaceId()
+	return err
 }
 
 type Project struct {
@@ -118,38 +140,19 @@ type Project struct {
 	Id   int32  `json:"id"`
 }
 
-func (client *TogglTrackClient) GetProjects() []Project {
+func (client *TogglTrackClient) GetProjects() ([]Project, error) {
 	client.logger.Info().Msg("Looking up Toggl projects")
-	defaultWorkpaceId := client.GetDefaultWorkspaceId()
-	projectsUrl := fmt.Sprintf("%s/workspaces/%d/projects", client.apiUrl, defaultWorkpaceId)
-	req, err := http.NewRequest(http.MethodGet, projectsUrl, nil)
+	defaultWorkpaceId, err := client.GetDefaultWorkspaceId()
 	if err != nil {
-		client.logger.Fatal().Err(err).Msg("Error while creating request.")
+		return nil, err
 	}
 
-	client.authenticateRequest(req)
-
-	res, err := client.httpClient.Do(req)
-	if err != nil {
-		client.logger.Fatal().Err(err).Msg("Error while sending request.")
-	}
-
-	client.logger.Debug().Str("status", res.Status).Msg("Prisla mi repsonse")
-	if res.StatusCode == 403 || res.StatusCode == 401 {
-		client.logger.Fatal().Msg("Authentication failed")
-	}
-
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		client.logger.Fatal().Err(err).Msg("Error while reading response body.")
-	}
-
-	client.logger.Debug().Str("response_body", fmt.Sprintf("%s", body)).Msg("Prislo mi response body")
+	projectsUrl := fmt.Sprintf("%s/workspaces/%d/projects", client.apiUrl, defaultWorkpaceId)
 	var projects []Project
-	err = json.Unmarshal(body, &projects)
+	err = client.get(projectsUrl, &projects)
 	if err != nil {
-		client.logger.Fatal().Err(err).Msg("Error while unmarshaling resonse payload.")
+		return nil, err
 	}
 
-	return projects
+	return projects, nil
 }
@@ -0,0 +1,123 @@
+package toggltrack
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"timetrack-sync/src/fakeapi"
+
+	"github.com/rs/zerolog"
+)
+
+func startFakeToggl(t *testing.T) (*fakeapi.Toggl, *TogglTrackClient) {
+	t.Helper()
+	projectId := int32(7)
+	fake := fakeapi.CreateToggl(fakeapi.TogglState{
+		ApiKey:      "api-key",
+		WorkspaceId: 42,
+		Projects:    []fakeapi.TogglProject{{Id: 7, Name: "Development"}},
+	})
+	fake.AddTimeEntry(fakeapi.TogglTimeEntry{
+		Id:          1,
+		ProjectId:   &projectId,
+		Start:       time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC),
+		Stop:        time.Date(2024, 3, 4, 10, 30, 0, 0, time.UTC),
+		Description: "Review",
+	})
+	fake.AddTimeEntry(fakeapi.TogglTimeEntry{
+		Id:    2,
+		Start: time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC),
+		Stop:  time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC),
+	})
+
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	logger := zerolog.Nop()
+	return fake, CreateTogglTrackClient(server.URL+fakeapi.TogglPrefix, "api-key", &logger)
+}
+
+func TestGetTimeEntriesFiltersRange(t *testing.T) {
+	_, client := startFakeToggl(t)
+
+	entries, err := client.GetTimeEntries(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("expected one entry, got %v", entries)
+	}
+	entry := entries[0]
+	if entry.ID != 1 || entry.ProjectID == nil || *entry.ProjectID != 7 || entry.Duration != 9000 || entry.Description != "Review" {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+}
+
+func TestGetProjectsUsesDefaultWorkspace(t *testing.T) {
+	_, client := startFakeToggl(t)
+
+	projects, err := client.GetProjects()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(projects) != 1 || projects[0].Id != 7 || projects[0].Name != "Development" {
+		t.Errorf("unexpected projects %v", projects)
+	}
+}
+
+func TestWrongApiKeyFailsAuthentication(t *testing.T) {
+	_, client := startFakeToggl(t)
+	client.apiKey = "wrong"
+
+	err := client.CheckConnection()
+	if !errors.Is(err, ErrAuthenticationFailed) {
+		t.Errorf("expected ErrAuthenticationFailed, got %v", err)
+	}
+}
+
+func TestInjectedFaults(t *testing.T) {
+	tests := []struct {
+		status   int
+		expected error
+	}{
+		{http.StatusUnauthorized, ErrAuthenticationFailed},
+		{http.StatusTooManyRequests, &StatusError{StatusCode: http.StatusTooManyRequests}},
+		{http.StatusInternalServerError, &StatusError{StatusCode: http.StatusInternalServerError}},
+	}
+
+	for _, test := range tests {
+		fake, client := startFakeToggl(t)
+		fake.Inject(fakeapi.Fault{PathPrefix: fakeapi.TogglPrefix + "/me", Status: test.status, Times: 1})
+
+		_, err := client.GetTimeEntries(time.Time{}, time.Now())
+		var statusError *StatusError
+		if errors.As(test.expected, &statusError) {
+			if !errors.As(err, &statusError) || statusError.StatusCode != test.status {
+				t.Errorf("%d: expected status error, got %v", test.status, err)
+			}
+		} else if !errors.Is(err, test.expected) {
+			t.Errorf("%d: expected %v, got %v", test.status, test.expected, err)
+		}
+
+		// the fault was injected only once
+		_, err = client.GetTimeEntries(time.Time{}, time.Now())
+		if err != nil {
+			t.Errorf("%d: expected the second request to succeed, got %v", test.status, err)
+		}
+	}
+}
+
+func TestSlowResponseTimesOut(t *testing.T) {
+	fake, client := startFakeToggl(t)
+	fake.Inject(fakeapi.Fault{Delay: time.Second})
+	client.SetHttpClient(&http.Client{Timeout: 50 * time.Millisecond})
+
+	_, err := client.GetDefaultWorkspaceId()
+	if err == nil {
+		t.Fatal("expected a timeout")
+	}
+}
@@ -22,6 +22,74 @@ func RoundTimeEntry(entry *toggltrack.TimeEntry) (*toggltrack.TimeEntry, error)
 	return entry, nil
 }
 
+// RoundTimeEntryInLocation converts entry times to the given location before rounding them,
+// so the rounded values (and everything derived from them) carry the local offset.
+func RoundTimeEntryInLocation(entry *toggltrack.TimeEntry, location *time.Location) (*toggltrack.TimeEntry, error) {
+	if entry == nil {
+		return nil, errors.New("Time entry may not be nil")
+	}
+
+	if location != nil {
+		entry.Start = entry.Start.In(location)
+		entry.Stop = entry.Stop.In(location)
+	}
+
+	return RoundTimeEntry(entry)
+}
+
+// StartOfDay returns local midnight of the day the given time falls into in the given location.
+// Unlike Truncate(24 * time.Hour), it respects the offset of the location including DST changes.
+func StartOfDay(value time.Time, location *time.Location) time.Time {
+	local := value.In(location)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+}
+
+// LoadLocation resolves a timezone name. Empty value and "Local" both mean the system timezone.
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return time.Local, nil
+	}
+
+	return time.LoadLocation(name)
+}
+
+// SplitTimeEntriesAtMidnight cuts entries spanning local midnight into separate per-day entries.
+// Split parts keep the ID and project of the original entry so they can be traced back to it.
+func SplitTimeEntriesAtMidnight(entries []toggltrack.TimeEntry, location *time.Location) []toggltrack.TimeEntry {
+	result := make([]toggltrack.TimeEntry, 0, len(entries))
+	for _, entry := range entries {
+		// running entries have no stop yet, there is nothing to split
+		if entry.Stop.IsZero() || !entry.Stop.After(entry.Start) {
+			result = append(result, entry)
+			continue
+		}
+
+		start := entry.Start.In(location)
+		stop := entry.Stop.In(location)
+		for {
+			nextMidnight := StartOfDay(start, location).AddDate(0, 0, 1)
+			if !stop.After(nextMidnight) {
+				break
+			}
+
+			part := entry
+			part.Start = start
+			part.Stop = nextMidnight
+			part.Duration = int64(nextMidnight.Sub(start).Seconds())
+			result = append(result, part)
+			start = nextMidnight
+		}
+
+		part := entry
+		part.Start = start
+		part.Stop = stop
+		part.Duration = int64(stop.Sub(start).Seconds())
+		result = append(result, part)
+	}
+
+	return result
+}
+
 func ParseDateString(value string, logger *zerolog.Logger, errorMessage *string) time.Time {
 	message := "Error while parsing date"
 	if errorMessage != nil {
@@ -36,6 +104,21 @@ func ParseDateString(value string, logger *zerolog.Logger, errorMessage *string)
 	return timeValue
 }
 
+// ParseDateStringInLocation parses a date as local midnight in the given location.
+func ParseDateStringInLocation(value string, location *time.Location, logger *zerolog.Logger, errorMessage *string) time.Time {
+	message := "Error while parsing date"
+	if errorMessage != nil {
+		message = *errorMessage
+	}
+
+	timeValue, err := time.ParseInLocation(time.DateOnly, value, location)
+	if err != nil {
+		logger.Fatal().Err(err).Msg(message)
+	}
+
+	return timeValue
+}
+
 func ParseDateTimeString(value string, logger *zerolog.Logger, errorMessage *string) time.Time {
 	message := "Error while parsing date time"
 	if errorMessage != nil {
@@ -50,28 +133,37 @@ func ParseDateTimeString(value string, logger *zerolog.Logger, errorMessage *str
 	return timeValue
 }
 
+// SloneekTarget is the Sloneek activity and optional category a Toggl project is booked to.
+type SloneekTarget struct {
+	Activity string `json:"activity"`
+	Category string `json:"category,omitempty"`
+}
+
+// ProjectMapping maps Toggl project names to Sloneek activities and categories.
+type ProjectMapping map[string]SloneekTarget
+
+var DefaultProjectMapping = ProjectMapping{
+	"Proteus":          {Activity: "Vývoj", Category: "Proteus"},
+	"Copilot":          {Activity: "Vývoj", Category: "Proteus"},
+	"Portál":           {Activity: "Vývoj", Category: "Portál"},
+	"Akvizice":         {Activity: "Vývoj", Category: "Akviziční formulář"},
+	"Flexi":            {Activity: "Vývoj", Category: "Flexi"},
+	"Interní":          {Activity: "Vývoj", Category: "Iternal job"},
+	"Hiring":           {Activity: "Hiring"},
+	"Admin & Meetings": {Activity: "Meeting"},
+}
+
+func (mapping ProjectMapping) Lookup(project string) (string, string) {
+	target, ok := mapping[project]
+	if !ok {
+		return "", ""
+	}
+
+	return target.Activity, target.Category
+}
+
 func MapTogglProjectToSloneekActivityAndCategory(project string) (string, string) {
-	// TODO this as an external config?
-	switch project {
-	case "Proteus":
-		return "Vývoj", "Proteus"
-	case "Copilot":
-		return "Vývoj", "Proteus"
-	case "Portál":
-		return "Vývoj", "Portál"
-	case "Akvizice":
-		return "Vývoj", "Akviziční formulář"
-	case "Flexi":
-		return "Vývoj", "Flexi"
-	case "Interní":
-		return "Vývoj", "Iternal job"
-	case "Hiring":
-		return "Hiring", ""
-	case "Admin & Meetings":
-		return "Meeting", ""
-	}
-
-	return "", ""
+	return DefaultProjectMapping.Lookup(project)
 }
 
 func MapTogglEntryToSloneekEntry(
@@ -80,6 +172,17 @@ func MapTogglEntryToSloneekEntry(
 	sloneekActivities []sloneek.Activity,
 	sloneekCategories []sloneek.Category,
 	logger *zerolog.Logger,
+) (*sloneek.TimeEntry, error) {
+	return MapTogglEntryToSloneekEntryWithMapping(entry, DefaultProjectMapping, togglProjects, sloneekActivities, sloneekCategories, logger)
+}
+
+func MapTogglEntryToSloneekEntryWithMapping(
+	entry *toggltrack.TimeEntry,
+	mapping ProjectMapping,
+	togglProjects []toggltrack.Project,
+	sloneekActivities []sloneek.Activity,
+	sloneekCategories []sloneek.Category,
+	logger *zerolog.Logger,
 ) (*sloneek.TimeEntry, error) {
 	logger.Debug().Any("entry", entry).Msg("Mapping toggl entry to sloneek entry")
 	projectIndex := slices.IndexFunc(togglProjects, func(project toggltrack.Project) bool { return project.Id == *entry.ProjectID })
@@ -89,7 +192,7 @@ func MapTogglEntryToSloneekEntry(
 	}
 
 	project := togglProjects[projectIndex]
-	activityName, categoryName := MapTogglProjectToSloneekActivityAndCategory(project.Name)
+	activityName, categoryName := mapping.Lookup(project.Name)
 	if activityName == "" {
 		logger.Error().Str("project", project.Name).Msg("Could not find matching activity")
 		return nil, errors.New("Could not find matching activity")
@@ -121,6 +224,7 @@ func MapTogglEntryToSloneekEntry(
 	}
 
 	sloneekEntry := &sloneek.TimeEntry{
+		SourceId:   entry.ID,
 		ActivityId: activity.Id,
 		CategoryId: categoryId,
 		Since:      entry.Start,
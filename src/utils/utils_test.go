@@ -3,6 +3,7 @@ package utils
 import (
 	"fmt"
 	"testing"
+	"time"
 	"timetrack-sync/src/sloneek"
 	testutils "timetrack-sync/src/testUtils"
 	toggltrack "timetrack-sync/src/togglTrack"
@@ -275,3 +276,188 @@ func TestMapToggleToSloneekWorksAsExpectedSecond(t *testing.T) {
 		t.Errorf("Unexpected end time found. Expected %s, got %s", entryStart, (*result).Since)
 	}
 }
+
+func loadPrague(t *testing.T) *time.Location {
+	t.Helper()
+	location, err := LoadLocation("Europe/Prague")
+	if err != nil {
+		t.Fatalf("Loading location failed: %v", err)
+	}
+
+	return location
+}
+
+func TestParseDateStringInLocationReturnsLocalMidnight(t *testing.T) {
+	location := loadPrague(t)
+
+	testCases := []struct {
+		Value       string
+		ExpectedUtc string
+	}{
+		{Value: "2024-01-01", ExpectedUtc: "2023-12-31 23:00:00"},
+		{Value: "2024-07-01", ExpectedUtc: "2024-06-30 22:00:00"},
+		// DST starts at 02:00 on this day, midnight is still in CET
+		{Value: "2024-03-31", ExpectedUtc: "2024-03-30 23:00:00"},
+		// DST ends at 03:00 on this day, midnight is still in CEST
+		{Value: "2024-10-27", ExpectedUtc: "2024-10-26 22:00:00"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Value, func(t *testing.T) {
+			expected := testutils.DateTimeFromString(testCase.ExpectedUtc, t)
+			result := ParseDateStringInLocation(testCase.Value, location, &zerolog.Logger{}, nil)
+
+			if !result.Equal(expected) {
+				t.Errorf("Expected %v, got %v.", expected, result.UTC())
+			}
+		})
+	}
+}
+
+func TestStartOfDayRespectsDstBoundaries(t *testing.T) {
+	location := loadPrague(t)
+
+	testCases := []struct {
+		Utc         string
+		ExpectedDay string
+	}{
+		// 23:30 UTC on the 30th is already 00:30 CET on the 31st
+		{Utc: "2024-03-30 23:30:00", ExpectedDay: "2024-03-31"},
+		// 22:30 UTC on the 31st is 00:30 CEST on April 1st
+		{Utc: "2024-03-31 22:30:00", ExpectedDay: "2024-04-01"},
+		{Utc: "2024-03-31 21:30:00", ExpectedDay: "2024-03-31"},
+		// 22:30 UTC on the 27th is 23:30 CET, still the 27th after the change
+		{Utc: "2024-10-27 22:30:00", ExpectedDay: "2024-10-27"},
+		{Utc: "2024-10-27 23:30:00", ExpectedDay: "2024-10-28"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Utc, func(t *testing.T) {
+			value := testutils.DateTimeFromString(testCase.Utc, t)
+			result := StartOfDay(value, location)
+
+			if result.Format(time.DateOnly) != testCase.ExpectedDay {
+				t.Errorf("Expected %s, got %s.", testCase.ExpectedDay, result.Format(time.DateOnly))
+			}
+			if result.Hour() != 0 || result.Minute() != 0 {
+				t.Errorf("Expected local midnight, got %v.", result)
+			}
+		})
+	}
+}
+
+func TestRoundTimeEntryInLocationConvertsToLocation(t *testing.T) {
+	location := loadPrague(t)
+
+	// 2024-10-27 00:52 UTC is 02:52 CEST, the last hour before DST ends
+	entry := toggltrack.TimeEntry{
+		ID:    1,
+		Start: testutils.DateTimeFromString("2024-10-27 00:52:00", t),
+		Stop:  testutils.DateTimeFromString("2024-10-27 01:08:00", t),
+	}
+
+	result, err := RoundTimeEntryInLocation(&entry, location)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Start.Location() != location || result.Stop.Location() != location {
+		t.Errorf("Expected times in %v, got %v and %v.", location, result.Start.Location(), result.Stop.Location())
+	}
+	if result.Start.Format("15:04 -0700") != "02:45 +0200" {
+		t.Errorf("Unexpected rounded start %s.", result.Start.Format("15:04 -0700"))
+	}
+	if result.Stop.Format("15:04 -0700") != "02:15 +0100" {
+		t.Errorf("Unexpected rounded stop %s.", result.Stop.Format("15:04 -0700"))
+	}
+	if result.Stop.Sub(result.Start) != 30*time.Minute {
+		t.Errorf("Expected 30 minutes across the DST change, got %v.", result.Stop.Sub(result.Start))
+	}
+}
+
+func TestSplitTimeEntriesAtMidnight(t *testing.T) {
+	location := loadPrague(t)
+
+	testCases := []struct {
+		Name           string
+		Start          string
+		Stop           string
+		ExpectedRanges [][2]string
+	}{
+		{
+			Name:           "same day",
+			Start:          "2024-01-10 08:00:00",
+			Stop:           "2024-01-10 10:00:00",
+			ExpectedRanges: [][2]string{{"2024-01-10 09:00", "2024-01-10 11:00"}},
+		},
+		{
+			Name:           "ends exactly at midnight",
+			Start:          "2024-01-10 21:00:00",
+			Stop:           "2024-01-10 23:00:00",
+			ExpectedRanges: [][2]string{{"2024-01-10 22:00", "2024-01-11 00:00"}},
+		},
+		{
+			Name:  "crosses midnight",
+			Start: "2024-01-10 21:00:00",
+			Stop:  "2024-01-11 00:30:00",
+			ExpectedRanges: [][2]string{
+				{"2024-01-10 22:00", "2024-01-11 00:00"},
+				{"2024-01-11 00:00", "2024-01-11 01:30"},
+			},
+		},
+		{
+			Name:  "spans multiple days",
+			Start: "2024-01-10 22:00:00",
+			Stop:  "2024-01-12 01:00:00",
+			ExpectedRanges: [][2]string{
+				{"2024-01-10 23:00", "2024-01-11 00:00"},
+				{"2024-01-11 00:00", "2024-01-12 00:00"},
+				{"2024-01-12 00:00", "2024-01-12 02:00"},
+			},
+		},
+		{
+			Name:  "crosses midnight before DST ends",
+			Start: "2024-10-26 20:00:00",
+			Stop:  "2024-10-27 02:00:00",
+			ExpectedRanges: [][2]string{
+				{"2024-10-26 22:00", "2024-10-27 00:00"},
+				{"2024-10-27 00:00", "2024-10-27 03:00"},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			projectId := int32(1)
+			entry := toggltrack.TimeEntry{
+				ID:        1,
+				ProjectID: &projectId,
+				Start:     testutils.DateTimeFromString(testCase.Start, t),
+				Stop:      testutils.DateTimeFromString(testCase.Stop, t),
+			}
+
+			result := SplitTimeEntriesAtMidnight([]toggltrack.TimeEntry{entry}, location)
+			if len(result) != len(testCase.ExpectedRanges) {
+				t.Fatalf("Expected %d entries, got %d: %v", len(testCase.ExpectedRanges), len(result), result)
+			}
+
+			totalDuration := int64(0)
+			for i, part := range result {
+				start := part.Start.Format("2006-01-02 15:04")
+				stop := part.Stop.Format("2006-01-02 15:04")
+				if start != testCase.ExpectedRanges[i][0] || stop != testCase.ExpectedRanges[i][1] {
+					t.Errorf("Unexpected part %d. Expected %v, got [%s %s]", i, testCase.ExpectedRanges[i], start, stop)
+				}
+				if part.ID != entry.ID || *part.ProjectID != projectId {
+					t.Errorf("Part %d lost its origin: %v", i, part)
+				}
+				totalDuration += part.Duration
+			}
+
+			expectedDuration := int64(entry.Stop.Sub(entry.Start).Seconds())
+			if totalDuration != expectedDuration {
+				t.Errorf("Expected total duration %d, got %d", expectedDuration, totalDuration)
+			}
+		})
+	}
+}
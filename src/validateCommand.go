@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"timetrack-sync/src/validation"
+
+	"github.com/rs/zerolog"
+)
+
+// workPolicy reads the policy file of TIMETRACK_WORK_POLICY and the mode of TIMETRACK_POLICY_MODE,
+// without them the default policy only warns.
+func (app *app) workPolicy() (*validation.Policy, validation.Mode, error) {
+	mode, err := validation.ParseMode(app.getenv("TIMETRACK_POLICY_MODE"))
+	if err != nil {
+		return nil, "", err
+	}
+
+	path := app.getenv("TIMETRACK_WORK_POLICY")
+	if path == "" {
+		policy := validation.DefaultPolicy()
+		return &policy, mode, nil
+	}
+
+	policy, err := validation.Load(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return policy, mode, nil
+}
+
+// policyViolations validates the prepared entries, nothing is checked in the off mode.
+func (app *app) policyViolations(prepared *syncContext) ([]validation.Violation, validation.Mode, error) {
+	policy, mode, err := app.workPolicy()
+	if err != nil || mode == validation.ModeOff {
+		return nil, mode, err
+	}
+
+	calendar, err := app.holidays()
+	if err != nil {
+		return nil, mode, err
+	}
+
+	return validation.Validate(prepared.sloneekEntries, policy, calendar, app.now(), prepared.location), mode, nil
+}
+
+// checkPolicy logs violations of the prepared entries and fails in the block mode.
+func (app *app) checkPolicy(prepared *syncContext, logger *zerolog.Logger) error {
+	violations, mode, err := app.policyViolations(prepared)
+	if err != nil {
+		return err
+	}
+
+	for _, violation := range violations {
+		logger.Warn().Str("day", violation.Day.Format("2006-01-02")).Str("rule", string(violation.Rule)).Msg(violation.Message)
+	}
+
+	if mode == validation.ModeBlock && len(violations) > 0 {
+		return fmt.Errorf("%w: %d violations", validation.ErrPolicyViolated, len(violations))
+	}
+
+	return nil
+}
+
+func runValidate(app *app, args []string) int {
+	logger := app.logger
+	flagSet := app.newFlagSet("validate", "validate [flags]")
+	flags := registerCommonFlags(flagSet, app.now())
+
+	if code, ok := parseFlags(flagSet, args); !ok {
+		return code
+	}
+
+	if app.resolveBearer(flags.bearerToken) == "" {
+		logger.Error().Msg("Sloneek JWT not found")
+		return exitUsage
+	}
+
+	location, since, until, err := resolveRange(flags)
+	if err != nil {
+		logger.Error().Err(err).Msg("Invalid range")
+		return exitUsage
+	}
+
+	policy, _, err := app.workPolicy()
+	if err != nil {
+		logger.Error().Err(err).Msg("Invalid working time policy")
+		return exitUsage
+	}
+
+	calendar, err := app.holidays()
+	if err != nil {
+		logger.Error().Err(err).Msg("Invalid holiday calendar")
+		return exitUsage
+	}
+
+	prepared, err := mapRange(app, app.defaultAccount(*flags.bearerToken), location, since, until, logger)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error while preparing sync")
+		return exitFailure
+	}
+
+	violations := validation.Validate(prepared.sloneekEntries, policy, calendar, app.now(), location)
+	err = validation.Render(violations, app.stdout)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error while rendering violations")
+		return exitFailure
+	}
+
+	if len(violations) > 0 {
+		return exitProblemsFound
+	}
+
+	return exitOk
+}
@@ -0,0 +1,176 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+	"timetrack-sync/src/holidays"
+	"timetrack-sync/src/sloneek"
+)
+
+var ErrPolicyViolated = errors.New("entries violate the working time policy")
+
+type Rule string
+
+const (
+	RuleMaxHours Rule = "max_hours"
+	RuleWeekend  Rule = "weekend"
+	RuleHoliday  Rule = "holiday"
+	RuleBreak    Rule = "break"
+	RuleFuture   Rule = "future"
+)
+
+// Mode says what happens with violations found before a sync.
+type Mode string
+
+const (
+	ModeOff   Mode = "off"
+	ModeWarn  Mode = "warn"
+	ModeBlock Mode = "block"
+)
+
+func ParseMode(value string) (Mode, error) {
+	switch Mode(value) {
+	case ModeOff, ModeWarn, ModeBlock:
+		return Mode(value), nil
+	case "":
+		return ModeWarn, nil
+	}
+
+	return "", fmt.Errorf("unknown policy mode %q, expected off, warn or block", value)
+}
+
+// Policy configures the checked rules. Zero hours disable the related rule.
+type Policy struct {
+	MaxHoursPerDay float64 `json:"max_hours_per_day"`
+	AllowWeekends  bool    `json:"allow_weekends"`
+	AllowHolidays  bool    `json:"allow_holidays"`
+	// AllowedDays (YYYY-MM-DD) may contain work on weekends and holidays, e.g. an agreed release
+	AllowedDays []string `json:"allowed_days,omitempty"`
+	// BreakAfterHours of continuous work require a break of at least MinBreakMinutes,
+	// the Czech labor code requires 30 minutes after 6 hours at the latest
+	BreakAfterHours float64 `json:"break_after_hours"`
+	MinBreakMinutes int     `json:"min_break_minutes"`
+	AllowFuture     bool    `json:"allow_future"`
+}
+
+func DefaultPolicy() Policy {
+	return Policy{MaxHoursPerDay: 12, BreakAfterHours: 6, MinBreakMinutes: 30}
+}
+
+// Load reads a policy file, rules missing in the file keep their defaults.
+func Load(path string) (*Policy, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := DefaultPolicy()
+	err = json.Unmarshal(content, &policy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid working time policy %s: %w", path, err)
+	}
+
+	return &policy, nil
+}
+
+type Violation struct {
+	Day     time.Time
+	Rule    Rule
+	Message string
+}
+
+// Validate checks mapped entries against the policy, violations are sorted by day. Days are
+// split in the location, holidays may be nil.
+func Validate(entries []sloneek.TimeEntry, policy *Policy, holidays holidays.Lookup, now time.Time, location *time.Location) []Violation {
+	days := map[time.Time][]sloneek.TimeEntry{}
+	for _, entry := range entries {
+		since := entry.Since.In(location)
+		day := time.Date(since.Year(), since.Month(), since.Day(), 0, 0, 0, 0, location)
+		days[day] = append(days[day], entry)
+	}
+
+	violations := []Violation{}
+	for day, dayEntries := range days {
+		violations = append(violations, validateDay(day, dayEntries, policy, holidays, now)...)
+	}
+
+	slices.SortStableFunc(violations, func(a, b Violation) int { return a.Day.Compare(b.Day) })
+	return violations
+}
+
+func validateDay(day time.Time, entries []sloneek.TimeEntry, policy *Policy, holidays holidays.Lookup, now time.Time) []Violation {
+	violations := []Violation{}
+	add := func(rule Rule, format string, args ...any) {
+		violations = append(violations, Violation{Day: day, Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	slices.SortFunc(entries, func(a, b sloneek.TimeEntry) int { return a.Since.Compare(b.Since) })
+	hours := 0.0
+	for _, entry := range entries {
+		hours += entry.GetHours()
+	}
+
+	if policy.MaxHoursPerDay > 0 && hours > policy.MaxHoursPerDay {
+		add(RuleMaxHours, "%.2f h tracked, at most %.2f h allowed", hours, policy.MaxHoursPerDay)
+	}
+
+	allowedDay := slices.Contains(policy.AllowedDays, day.Format(time.DateOnly))
+	if !policy.AllowWeekends && !allowedDay && (day.Weekday() == time.Saturday || day.Weekday() == time.Sunday) {
+		add(RuleWeekend, "work on %s", day.Weekday())
+	}
+	if holidays != nil && !policy.AllowHolidays && !allowedDay {
+		if name, ok := holidays.Holiday(day); ok {
+			add(RuleHoliday, "work on public holiday %s", name)
+		}
+	}
+
+	if policy.BreakAfterHours > 0 {
+		minBreak := time.Duration(policy.MinBreakMinutes) * time.Minute
+		blockStart, blockEnd := entries[0].Since, entries[0].Until
+		checkBlock := func() {
+			if blockEnd.Sub(blockStart).Hours() > policy.BreakAfterHours {
+				add(RuleBreak, "%s-%s worked without a break of %d minutes after %.1f h",
+					blockStart.In(day.Location()).Format("15:04"), blockEnd.In(day.Location()).Format("15:04"), policy.MinBreakMinutes, policy.BreakAfterHours)
+			}
+		}
+
+		for _, entry := range entries[1:] {
+			if entry.Since.Sub(blockEnd) >= minBreak {
+				checkBlock()
+				blockStart = entry.Since
+			}
+			if entry.Until.After(blockEnd) {
+				blockEnd = entry.Until
+			}
+		}
+		checkBlock()
+	}
+
+	if !policy.AllowFuture {
+		for _, entry := range entries {
+			if entry.Until.After(now) {
+				add(RuleFuture, "entry ending %s is in the future", entry.Until.In(day.Location()).Format("2006-01-02 15:04"))
+				break
+			}
+		}
+	}
+
+	return violations
+}
+
+// Render prints violations grouped by day.
+func Render(violations []Violation, writer io.Writer) error {
+	tableWriter := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tableWriter, "Day\tRule\tViolation\t")
+	for _, violation := range violations {
+		fmt.Fprintf(tableWriter, "%s\t%s\t%s\t\n", violation.Day.Format("Mon 2006-01-02"), violation.Rule, violation.Message)
+	}
+
+	return tableWriter.Flush()
+}
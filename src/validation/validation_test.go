@@ -0,0 +1,110 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"timetrack-sync/src/sloneek"
+	testutils "timetrack-sync/src/testUtils"
+)
+
+var prague, _ = time.LoadLocation("Europe/Prague")
+
+func entry(day string, since string, until string) sloneek.TimeEntry {
+	parse := func(value string) time.Time {
+		parsed, _ := time.ParseInLocation(time.DateTime, day+" "+value+":00", prague)
+		return parsed
+	}
+
+	return sloneek.TimeEntry{ActivityId: "a1", Since: parse(since), Until: parse(until)}
+}
+
+var now = time.Date(2024, 6, 1, 0, 0, 0, 0, prague)
+
+func rules(violations []Violation) string {
+	result := []string{}
+	for _, violation := range violations {
+		result = append(result, violation.Day.Format(time.DateOnly)+" "+string(violation.Rule))
+	}
+
+	return strings.Join(result, ", ")
+}
+
+func TestValidEntriesPass(t *testing.T) {
+	policy := DefaultPolicy()
+	entries := []sloneek.TimeEntry{
+		entry("2024-03-04", "08:00", "12:00"),
+		entry("2024-03-04", "12:30", "16:30"),
+	}
+
+	violations := Validate(entries, &policy, nil, now, prague)
+	if len(violations) != 0 {
+		t.Errorf("expected no violations, got %v", violations)
+	}
+}
+
+func TestBreakAfterSixHours(t *testing.T) {
+	policy := DefaultPolicy()
+	entries := []sloneek.TimeEntry{
+		// a 15 minute pause is not a break
+		entry("2024-03-04", "08:00", "11:00"),
+		entry("2024-03-04", "11:15", "14:30"),
+		// exactly six hours are fine
+		entry("2024-03-05", "08:00", "14:00"),
+	}
+
+	violations := Validate(entries, &policy, nil, now, prague)
+	if rules(violations) != "2024-03-04 break" {
+		t.Fatalf("expected one break violation, got %v", violations)
+	}
+	if !strings.Contains(violations[0].Message, "08:00-14:30") {
+		t.Errorf("expected the continuous block in the message, got %q", violations[0].Message)
+	}
+}
+
+func TestMaxHoursWeekendsHolidaysAndFuture(t *testing.T) {
+	policy := DefaultPolicy()
+	policy.MaxHoursPerDay = 8
+	entries := []sloneek.TimeEntry{
+		entry("2024-03-04", "07:00", "12:00"),
+		entry("2024-03-04", "13:00", "17:00"),
+		entry("2024-03-09", "10:00", "11:00"),
+		entry("2024-04-01", "10:00", "11:00"),
+		entry("2024-06-03", "10:00", "11:00"),
+	}
+	holidays := testutils.FixedHolidays{"2024-04-01": "Velikonoční pondělí"}
+
+	violations := Validate(entries, &policy, holidays, now, prague)
+	expected := "2024-03-04 max_hours, 2024-03-09 weekend, 2024-04-01 holiday, 2024-06-03 future"
+	if rules(violations) != expected {
+		t.Errorf("expected %s, got %s", expected, rules(violations))
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	policy := DefaultPolicy()
+	policy.AllowedDays = []string{"2024-03-09"}
+	policy.AllowFuture = true
+	entries := []sloneek.TimeEntry{
+		entry("2024-03-09", "10:00", "11:00"),
+		entry("2024-03-10", "10:00", "11:00"),
+		entry("2024-06-03", "10:00", "11:00"),
+	}
+
+	violations := Validate(entries, &policy, nil, now, prague)
+	if rules(violations) != "2024-03-10 weekend" {
+		t.Errorf("expected only the not allowed Sunday, got %v", violations)
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	mode, err := ParseMode("")
+	if err != nil || mode != ModeWarn {
+		t.Errorf("expected warn by default, got %v %v", mode, err)
+	}
+
+	_, err = ParseMode("strict")
+	if err == nil {
+		t.Error("expected an error for unknown mode")
+	}
+}
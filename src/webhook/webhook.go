@@ -0,0 +1,176 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	toggltrack "timetrack-sync/src/togglTrack"
+	"timetrack-sync/src/utils"
+
+	"github.com/rs/zerolog"
+)
+
+const SignatureHeader = "X-Webhook-Signature-256"
+
+// requests bigger than this are certainly not Toggl events
+const maxBodySize = 1 << 20
+
+type EventMetadata struct {
+	Action string `json:"action"`
+	Model  string `json:"model"`
+}
+
+// Event is a Toggl Track webhook event. Payload holds the affected entity, or the string "ping"
+// for the subscription validation event.
+type Event struct {
+	EventId        int64           `json:"event_id"`
+	CreatedAt      time.Time       `json:"created_at"`
+	Metadata       EventMetadata   `json:"metadata"`
+	Payload        json.RawMessage `json:"payload"`
+	ValidationCode string          `json:"validation_code,omitempty"`
+}
+
+func (event *Event) isPing() bool {
+	return strings.TrimSpace(string(event.Payload)) == `"ping"`
+}
+
+// VerifySignature checks the "sha256=<hex HMAC>" header value against the raw body.
+func VerifySignature(secret string, body []byte, header string) bool {
+	signature, found := strings.CutPrefix(header, "sha256=")
+	if !found {
+		return false
+	}
+
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// Queue collects days affected by webhook events. A day already waiting is not queued again,
+// so bursts of edits to the same day result in a single sync.
+type Queue struct {
+	days    chan time.Time
+	mutex   sync.Mutex
+	pending map[time.Time]bool
+}
+
+func CreateQueue(size int) *Queue {
+	return &Queue{days: make(chan time.Time, size), pending: make(map[time.Time]bool)}
+}
+
+// Enqueue returns false when the queue is full and the day was dropped.
+func (queue *Queue) Enqueue(day time.Time) bool {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+	if queue.pending[day] {
+		return true
+	}
+
+	select {
+	case queue.days <- day:
+		queue.pending[day] = true
+		return true
+	default:
+		return false
+	}
+}
+
+// Run syncs queued days one by one until the context is canceled.
+func (queue *Queue) Run(ctx context.Context, syncDay func(ctx context.Context, day time.Time) error, logger *zerolog.Logger) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case day := <-queue.days:
+			queue.mutex.Lock()
+			delete(queue.pending, day)
+			queue.mutex.Unlock()
+
+			logger.Info().Time("day", day).Msg("Syncing day affected by webhook")
+			err := syncDay(ctx, day)
+			if err != nil {
+				logger.Error().Err(err).Time("day", day).Msg("Webhook sync failed")
+			}
+		}
+	}
+}
+
+type Handler struct {
+	Secret   string
+	Queue    *Queue
+	Location *time.Location
+	Logger   *zerolog.Logger
+}
+
+func (handler *Handler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodPost {
+		http.Error(writer, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(request.Body, maxBodySize))
+	if err != nil {
+		http.Error(writer, "cannot read body", http.StatusBadRequest)
+		return
+	}
+
+	if !VerifySignature(handler.Secret, body, request.Header.Get(SignatureHeader)) {
+		handler.Logger.Warn().Str("remote", request.RemoteAddr).Msg("Webhook with invalid signature rejected")
+		http.Error(writer, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var event Event
+	err = json.Unmarshal(body, &event)
+	if err != nil {
+		http.Error(writer, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	if event.isPing() {
+		handler.Logger.Info().Msg("Webhook subscription validated")
+		writer.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(writer).Encode(map[string]string{"validation_code": event.ValidationCode})
+		return
+	}
+
+	if event.Metadata.Model != "time_entry" {
+		handler.Logger.Debug().Str("model", event.Metadata.Model).Msg("Ignoring webhook event")
+		writer.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var entry toggltrack.TimeEntry
+	err = json.Unmarshal(event.Payload, &entry)
+	if err != nil || entry.Start.IsZero() {
+		http.Error(writer, "invalid time entry payload", http.StatusBadRequest)
+		return
+	}
+
+	// Every day the entry covers is resynced, entries crossing midnight are split into parts.
+	// Copies of deleted entries are only removed with the webhook -delete flag. Payloads of
+	// updates carry the new start only, the old day of a moved entry keeps its copy until a
+	// sync with deletes covers it, e.g. the daemon with -delete.
+	handler.Logger.Info().Str("action", event.Metadata.Action).Int64("entry_id", entry.ID).Msg("Time entry webhook received")
+	for _, part := range utils.SplitTimeEntriesAtMidnight([]toggltrack.TimeEntry{entry}, handler.Location) {
+		day := utils.StartOfDay(part.Start, handler.Location)
+		if !handler.Queue.Enqueue(day) {
+			http.Error(writer, "queue full", http.StatusServiceUnavailable)
+			return
+		}
+	}
+
+	writer.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,149 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const testSecret = "webhook-secret"
+
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// postRecorded plays the role of Toggl, posting a recorded payload to the receiver.
+func postRecorded(t *testing.T, serverUrl string, name string, secret string) *http.Response {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request, err := http.NewRequest(http.MethodPost, serverUrl, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set(SignatureHeader, sign(secret, body))
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { response.Body.Close() })
+
+	return response
+}
+
+func startReceiver(t *testing.T) (*httptest.Server, *Queue) {
+	t.Helper()
+	location, err := time.LoadLocation("Europe/Prague")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	queue := CreateQueue(10)
+	server := httptest.NewServer(&Handler{Secret: testSecret, Queue: queue, Location: location, Logger: &zerolog.Logger{}})
+	t.Cleanup(server.Close)
+
+	return server, queue
+}
+
+func TestReceiverAnswersValidationPing(t *testing.T) {
+	server, _ := startReceiver(t)
+
+	response := postRecorded(t, server.URL, "ping.json", testSecret)
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("Unexpected status %d", response.StatusCode)
+	}
+
+	var payload map[string]string
+	err := json.NewDecoder(response.Body).Decode(&payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload["validation_code"] != "abc-123" {
+		t.Errorf("Unexpected validation response %v", payload)
+	}
+}
+
+func TestReceiverRejectsInvalidSignature(t *testing.T) {
+	server, queue := startReceiver(t)
+
+	response := postRecorded(t, server.URL, "time_entry_created.json", "wrong-secret")
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401, got %d", response.StatusCode)
+	}
+	if len(queue.days) != 0 {
+		t.Errorf("Expected nothing to be queued")
+	}
+}
+
+func TestReceiverQueuesAffectedDays(t *testing.T) {
+	server, queue := startReceiver(t)
+
+	testCases := []struct {
+		File           string
+		ExpectedStatus int
+	}{
+		{File: "time_entry_created.json", ExpectedStatus: http.StatusAccepted},
+		{File: "time_entry_deleted.json", ExpectedStatus: http.StatusAccepted},
+		{File: "project_updated.json", ExpectedStatus: http.StatusNoContent},
+	}
+
+	for _, testCase := range testCases {
+		response := postRecorded(t, server.URL, testCase.File, testSecret)
+		if response.StatusCode != testCase.ExpectedStatus {
+			t.Errorf("%s: expected status %d, got %d", testCase.File, testCase.ExpectedStatus, response.StatusCode)
+		}
+	}
+
+	// created entry starts at 00:30 Prague time on the 3rd, the deleted one on the same day,
+	// so a single sync is queued
+	if len(queue.days) != 1 {
+		t.Fatalf("Expected one queued day, got %d", len(queue.days))
+	}
+
+	synced := []string{}
+	ctx, cancel := context.WithCancel(context.Background())
+	queue.Run(ctx, func(ctx context.Context, day time.Time) error {
+		synced = append(synced, day.Format(time.DateOnly))
+		cancel()
+		return nil
+	}, &zerolog.Logger{})
+
+	if len(synced) != 1 || synced[0] != "2024-09-03" {
+		t.Errorf("Unexpected synced days %v", synced)
+	}
+}
+
+func TestReceiverQueuesEveryDayOfEntryCrossingMidnight(t *testing.T) {
+	server, queue := startReceiver(t)
+
+	// 23:00 to 01:00 Prague time
+	response := postRecorded(t, server.URL, "time_entry_updated_midnight.json", testSecret)
+	if response.StatusCode != http.StatusAccepted {
+		t.Fatalf("Expected status %d, got %d", http.StatusAccepted, response.StatusCode)
+	}
+
+	queued := []string{}
+	for len(queue.days) > 0 {
+		queued = append(queued, (<-queue.days).Format(time.DateOnly))
+	}
+	if len(queued) != 2 || queued[0] != "2024-09-04" || queued[1] != "2024-09-05" {
+		t.Errorf("Expected both days queued, got %v", queued)
+	}
+}
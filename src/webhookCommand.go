@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+	"timetrack-sync/src/utils"
+	"timetrack-sync/src/webhook"
+)
+
+func runWebhook(app *app, args []string) int {
+	logger := app.logger
+	flagSet := app.newFlagSet("webhook", "webhook [flags]")
+	bearerToken := flagSet.String("bearer", "", "Bearer token obtained after login to Sloneek app")
+	timezone := flagSet.String("timezone", "Local", "IANA timezone (e.g. Europe/Prague) used for day boundaries and rounding.")
+	listen := flagSet.String("listen", ":8080", "Address to receive Toggl webhooks on.")
+	allowDelete := flagSet.Bool("delete", false, "Delete synced Sloneek entries whose Toggl entry is gone. Off by default as nobody reviews webhook syncs.")
+	lockPath := flagSet.String("lock", defaultLockPath, "Lock file preventing concurrent syncs, shared with the daemon and serve commands.")
+	secret := flagSet.String("secret", app.getenv("TOGGL_WEBHOOK_SECRET"), "Secret of the Toggl webhook subscription. Defaults to TOGGL_WEBHOOK_SECRET.")
+
+	if code, ok := parseFlags(flagSet, args); !ok {
+		return code
+	}
+
+	if app.resolveBearer(bearerToken) == "" {
+		logger.Error().Msg("Sloneek JWT not found")
+		return exitUsage
+	}
+	app.addSecrets(*secret)
+	if *secret == "" {
+		logger.Error().Msg("Webhook secret not set, refusing to accept unsigned events")
+		return exitUsage
+	}
+
+	location, err := utils.LoadLocation(*timezone)
+	if err != nil {
+		logger.Error().Err(err).Str("timezone", *timezone).Msg("Unknown timezone")
+		return exitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(app.ctx, syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	queue := webhook.CreateQueue(100)
+	go queue.Run(ctx, func(ctx context.Context, day time.Time) error {
+		return lockedSync(ctx, *lockPath, func() error {
+			return syncRange(app, app.defaultAccount(*bearerToken), location, day, day.AddDate(0, 0, 1), *allowDelete, logger)
+		})
+	}, logger)
+
+	webhookLogger := logger.With().Str("component", "webhook").Logger()
+	mux := http.NewServeMux()
+	mux.Handle("/webhooks/toggl", &webhook.Handler{Secret: *secret, Queue: queue, Location: location, Logger: &webhookLogger})
+	server := &http.Server{Addr: *listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	logger.Info().Str("listen", *listen).Msg("Receiving Toggl webhooks on /webhooks/toggl")
+	err = server.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Error().Err(err).Msg("Webhook server failed")
+		return exitFailure
+	}
+
+	return exitOk
+}